@@ -0,0 +1,3 @@
+module github.com/jmag-ic/gosura
+
+go 1.22
@@ -0,0 +1,215 @@
+package ast
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrFieldNotAllowed is reported by Allowlist.Validate for a field that is
+// not in the allowlist.
+var ErrFieldNotAllowed = errors.New("field not allowed")
+
+// Allowlist restricts the fields a filter may reference.
+type Allowlist struct {
+	fields    map[string]bool
+	relations map[string]bool
+	subtrees  []string
+}
+
+// NewAllowlist returns an Allowlist of dotted field paths, e.g. "id" or
+// "user.email". A path ending in ".*" allows every field under it, e.g.
+// "user.*". The relations leading to an allowed path may be used without
+// conditions, e.g. {"user": {}} is allowed by "user.email".
+func NewAllowlist(paths ...string) *Allowlist {
+	a := &Allowlist{fields: make(map[string]bool), relations: make(map[string]bool)}
+	for _, path := range paths {
+		if prefix, ok := strings.CutSuffix(path, ".*"); ok {
+			a.subtrees = append(a.subtrees, prefix+".")
+			a.relations[prefix] = true
+			path = prefix
+		} else {
+			a.fields[path] = true
+		}
+		for i, c := range path {
+			if c == '.' {
+				a.relations[path[:i]] = true
+			}
+		}
+	}
+	return a
+}
+
+// StructAllowlist returns an Allowlist of the fields of the struct type of
+// v, which may be a struct, a pointer to one or a reflect.Type, so a model
+// struct can be the single source of truth for what clients may filter on.
+//
+// Fields are named by their json tag, then their db tag, then their Go
+// name. Fields tagged "-" and unexported fields are skipped, and the fields
+// of embedded structs without a tag are promoted. Fields holding structs,
+// or pointers, slices or arrays of structs, are relations whose fields are
+// added under their name, e.g. "user.email". Structs that marshal
+// themselves, such as time.Time, are plain fields. A struct type that
+// appears again within its own relations is not descended into a second
+// time.
+func StructAllowlist(v any) *Allowlist {
+	t, ok := v.(reflect.Type)
+	if !ok {
+		t = reflect.TypeOf(v)
+	}
+	var paths []string
+	structPaths(t, "", map[reflect.Type]bool{}, &paths)
+	return NewAllowlist(paths...)
+}
+
+var (
+	jsonMarshaler = reflect.TypeFor[json.Marshaler]()
+	textMarshaler = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+func structPaths(t reflect.Type, prefix string, visiting map[reflect.Type]bool, paths *[]string) {
+	if t == nil {
+		return
+	}
+	t = relationType(t)
+	if t == nil || visiting[t] {
+		return
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, tagged := fieldName(field)
+		switch {
+		case name == "-":
+			continue
+		case field.Anonymous && !tagged && relationType(field.Type) != nil:
+			structPaths(field.Type, prefix, visiting, paths)
+			continue
+		case !field.IsExported():
+			continue
+		}
+
+		path := prefix + name
+		if relationType(field.Type) == nil {
+			*paths = append(*paths, path)
+			continue
+		}
+		structPaths(field.Type, path+".", visiting, paths)
+	}
+}
+
+// fieldName returns the filter name of field and whether it comes from a
+// tag.
+func fieldName(field reflect.StructField) (string, bool) {
+	for _, key := range []string{"json", "db"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(key), ","); name != "" {
+			return name, true
+		}
+	}
+	return field.Name, false
+}
+
+// relationType returns the struct type held by a field of type t when the
+// field is a relation, and nil otherwise.
+func relationType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	for _, m := range []reflect.Type{jsonMarshaler, textMarshaler} {
+		if t.Implements(m) || reflect.PointerTo(t).Implements(m) {
+			return nil
+		}
+	}
+	return t
+}
+
+// Validate reports the first field of f that is not allowed, checking the
+// where, order_by and aggregate sections in that order. The returned error
+// is an *Error wrapping ErrFieldNotAllowed whose Path follows the parser's
+// convention and ends with the field, e.g. "where._or[0].user.password",
+// "order_by[1].user.password" or "aggregate.sum.salary". The aggregate
+// field "*" is always allowed.
+func (a *Allowlist) Validate(f *Filter) error {
+	if f.Where != nil {
+		if err := a.validateWhere(f.Where, nil, "where"); err != nil {
+			return err
+		}
+	}
+
+	for i, entry := range f.OrderBy {
+		field := strings.Join(entry.Path, ".")
+		if !a.allows(field) {
+			return &Error{Path: fmt.Sprintf("order_by[%d].%s", i, field), Err: ErrFieldNotAllowed}
+		}
+	}
+
+	for _, agg := range f.Aggregates {
+		for _, field := range agg.Fields {
+			if field != "*" && !a.allows(field) {
+				return &Error{Path: "aggregate." + agg.Function + "." + field, Err: ErrFieldNotAllowed}
+			}
+		}
+	}
+	return nil
+}
+
+// validateWhere checks node, found under the relations in fields at path.
+func (a *Allowlist) validateWhere(node WhereNode, fields []string, path string) error {
+	switch n := node.(type) {
+	case *Comparison:
+		if !a.allows(joinFields(fields, n.Field)) {
+			return &Error{Path: path + "." + n.Field, Err: ErrFieldNotAllowed}
+		}
+	case *Relation:
+		field := joinFields(fields, n.Field)
+		p := path + "." + n.Field
+		if !a.relations[field] && !a.allows(field) {
+			return &Error{Path: p, Err: ErrFieldNotAllowed}
+		}
+		if n.Where != nil {
+			return a.validateWhere(n.Where, append(fields[:len(fields):len(fields)], n.Field), p)
+		}
+	case *LogicalGroup:
+		for i, child := range n.Children {
+			p := path
+			switch {
+			case n.Implicit:
+			case n.Op == Not:
+				p += "." + string(Not)
+			default:
+				p += fmt.Sprintf(".%s[%d]", n.Op, i)
+			}
+			if err := a.validateWhere(child, fields, p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (a *Allowlist) allows(field string) bool {
+	if a.fields[field] {
+		return true
+	}
+	for _, prefix := range a.subtrees {
+		if strings.HasPrefix(field, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func joinFields(path []string, field string) string {
+	if len(path) == 0 {
+		return field
+	}
+	return strings.Join(path, ".") + "." + field
+}
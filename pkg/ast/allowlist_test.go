@@ -0,0 +1,112 @@
+package ast
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAllowlist(t *testing.T) {
+	allowlist := NewAllowlist("id", "name", "user.email", "user.posts.title", "tags.*")
+
+	tests := []struct {
+		name     string
+		filter   string
+		wantPath string
+	}{
+		{name: "empty", filter: `{}`},
+		{name: "allowed fields", filter: `{"where":{"_or":[{"id":{"_eq":1}},{"_not":{"name":{"_like":"x%"}}}]}}`},
+		{name: "allowed relation paths", filter: `{"where":{"user":{"email":{"_eq":"x"},"posts":{"title":{"_eq":"y"}}}}}`},
+		{name: "relation prefix without conditions", filter: `{"where":{"user":{"posts":{}}}}`},
+		{name: "subtree", filter: `{"where":{"tags":{"label":{"_eq":"x"},"owner":{"id":{"_eq":1}}}},"order_by":{"tags":{"label":"asc"}}}`},
+		{name: "allowed order_by and aggregate", filter: `{"order_by":[{"user":{"email":"asc"}},{"id":"desc"}],"aggregate":{"count":"*","max":"user.email"}}`},
+		{name: "unknown field", filter: `{"where":{"id":{"_eq":1},"password":{"_eq":"x"}}}`, wantPath: "where.password"},
+		{name: "unknown nested field", filter: `{"where":{"_or":[{"user":{"password":{"_eq":"x"}}}]}}`, wantPath: "where._or[0].user.password"},
+		{name: "unknown relation", filter: `{"where":{"secrets":{}}}`, wantPath: "where.secrets"},
+		{name: "relation used as a column", filter: `{"where":{"user":{"_is_null":true}}}`, wantPath: "where.user"},
+		{name: "unknown order_by field", filter: `{"order_by":{"user":{"password":"asc"}}}`, wantPath: "order_by[0].user.password"},
+		{name: "unknown field in _not", filter: `{"where":{"_and":[{"id":{"_eq":1}},{"_not":{"password":{"_eq":"x"}}}]}}`, wantPath: "where._and[1]._not.password"},
+		{name: "unknown order_by field after others", filter: `{"order_by":[{"id":"asc"},{"user":{"password":"asc"}}]}`, wantPath: "order_by[1].user.password"},
+		{name: "unknown aggregate field", filter: `{"aggregate":{"sum":{"fields":["id","salary"]}}}`, wantPath: "aggregate.sum.salary"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(tt.filter)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			err = allowlist.Validate(f)
+			if tt.wantPath == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			var astErr *Error
+			if !errors.As(err, &astErr) || !errors.Is(err, ErrFieldNotAllowed) {
+				t.Fatalf("Validate() error = %v, want *Error wrapping ErrFieldNotAllowed", err)
+			}
+			if astErr.Path != tt.wantPath {
+				t.Errorf("Path = %q, want %q", astErr.Path, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestStructAllowlist(t *testing.T) {
+	type Audit struct {
+		CreatedAt time.Time `json:"created_at"`
+	}
+	type Post struct {
+		Title  string `db:"title"`
+		Body   string `json:"-"`
+		Author *user  `json:"author"`
+	}
+	type Model struct {
+		Audit
+		ID       int64  `json:"id,omitempty"`
+		Email    string `json:"email" db:"email_address"`
+		Name     string
+		password string
+		Posts    []Post `json:"posts"`
+		Profile  *user  `json:"profile"`
+	}
+
+	allowlist := StructAllowlist(&Model{})
+	for _, filter := range []string{
+		`{"where":{"id":{"_eq":1},"email":{"_eq":"x"},"Name":{"_eq":"y"},"created_at":{"_gt":"2024-01-01"}}}`,
+		`{"where":{"posts":{"title":{"_eq":"x"},"author":{"nickname":{"_eq":"y"}}}}}`,
+		`{"where":{"profile":{"nickname":{"_eq":"x"}}},"order_by":{"profile":{"nickname":"asc"}}}`,
+	} {
+		f, err := Parse(filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := allowlist.Validate(f); err != nil {
+			t.Errorf("Validate(%s) error = %v, want nil", filter, err)
+		}
+	}
+
+	for _, filter := range []string{
+		`{"where":{"email_address":{"_eq":"x"}}}`,
+		`{"where":{"password":{"_eq":"x"}}}`,
+		`{"where":{"posts":{"Body":{"_eq":"x"}}}}`,
+		`{"where":{"Audit":{"created_at":{"_eq":"x"}}}}`,
+		`{"where":{"created_at":{"wall":{"_eq":1}}}}`,
+		`{"where":{"profile":{"friend":{"nickname":{"_eq":"x"}}}}}`,
+	} {
+		f, err := Parse(filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := allowlist.Validate(f); !errors.Is(err, ErrFieldNotAllowed) {
+			t.Errorf("Validate(%s) error = %v, want ErrFieldNotAllowed", filter, err)
+		}
+	}
+}
+
+type user struct {
+	Nickname string `json:"nickname"`
+	Friend   *user  `json:"friend"`
+}
@@ -0,0 +1,118 @@
+// Package ast parses Hasura-style filter documents into typed Go structs.
+//
+// It is an alternative to the streaming hook interface for code that needs
+// the whole filter at once, such as validation, rewriting or optimization.
+//
+// The annotation keys _meta and _comment may appear in any where object.
+// They are kept in the Meta field of the node they annotate and have no
+// effect on the meaning of the filter. At the top level of a document they
+// are kept in Filter.Extra like any other unknown section.
+package ast
+
+import "encoding/json"
+
+// Filter is a parsed filter document.
+type Filter struct {
+	// Where is nil when the document has no where section or it is empty.
+	Where      WhereNode
+	OrderBy    []OrderBy
+	Limit      *int64
+	Offset     *int64
+	Aggregates []Aggregate
+	// Extra holds top-level sections this package does not interpret,
+	// keyed by section name.
+	Extra map[string]json.RawMessage
+}
+
+// WhereNode is a node of a where tree: *LogicalGroup, *Comparison or
+// *Relation.
+type WhereNode interface {
+	whereNode()
+}
+
+// LogicalOp is the operator of a LogicalGroup.
+type LogicalOp string
+
+const (
+	And LogicalOp = "_and"
+	Or  LogicalOp = "_or"
+	Not LogicalOp = "_not"
+)
+
+// LogicalGroup combines its children with Op. A Not group has exactly one
+// child.
+type LogicalGroup struct {
+	Op       LogicalOp
+	Children []WhereNode
+	// Implicit reports whether an And group came from an object with
+	// several members, e.g. {"a": {...}, "b": {...}}, rather than from an
+	// explicit _and list.
+	Implicit bool
+	// Meta holds the _meta and _comment annotations of the object the
+	// group came from.
+	Meta map[string]any
+}
+
+// Comparison applies Operator with Value to the column Field.
+//
+// Value holds the decoded JSON operand: string, json.Number, bool, nil,
+// []any or map[string]any.
+type Comparison struct {
+	Field    string
+	Operator string
+	Value    any
+	// Meta holds the _meta and _comment annotations written next to the
+	// operator. When a column object has several operators, the
+	// annotations are attached to the first comparison.
+	Meta map[string]any
+}
+
+// Relation applies Where to the related rows reached through Field. Where
+// is nil for a relation without conditions, e.g. {"user": {}}.
+type Relation struct {
+	Field string
+	Where WhereNode
+}
+
+func (*LogicalGroup) whereNode() {}
+func (*Comparison) whereNode()   {}
+func (*Relation) whereNode()     {}
+
+// OrderBy is a single order_by entry. Path has more than one element when
+// ordering by a field of a relation.
+type OrderBy struct {
+	Path      []string
+	Direction string
+}
+
+// Aggregate is an entry of the aggregate section.
+type Aggregate struct {
+	Function string
+	Fields   []string
+	// Options holds the remaining members of an object-form aggregate,
+	// e.g. {"field": "price", "percentile": 0.5}.
+	Options map[string]any
+}
+
+// WalkFunc is called by Walk for each node. path is the relation path
+// leading to node. Returning false skips the children of node.
+type WalkFunc func(node WhereNode, path []string) bool
+
+// Walk traverses the tree rooted at node in depth-first order.
+func Walk(node WhereNode, fn WalkFunc) {
+	walk(node, nil, fn)
+}
+
+func walk(node WhereNode, path []string, fn WalkFunc) {
+	if node == nil || !fn(node, path) {
+		return
+	}
+	switch n := node.(type) {
+	case *LogicalGroup:
+		for _, child := range n.Children {
+			walk(child, path, fn)
+		}
+	case *Relation:
+		walk(n.Where, append(path[:len(path):len(path)], n.Field), fn)
+	}
+}
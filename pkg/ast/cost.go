@@ -0,0 +1,84 @@
+package ast
+
+// CostModel holds the weights used by Filter.Cost.
+type CostModel struct {
+	// Operators maps comparison operators to their weight. Operators that
+	// are not listed weigh DefaultOperator.
+	Operators       map[string]int
+	DefaultOperator int
+	// Relation is added for each relation traversed, e.g. twice for
+	// {"user": {"posts": {...}}}.
+	Relation int
+	// Depth is added to a comparison once for each relation enclosing it.
+	Depth int
+	// Group is added for each explicit _and, _or and _not group, so
+	// deeply nested groups are not free.
+	Group int
+	// ListItem is added for each element of a list operand, e.g. of _in.
+	ListItem int
+	// OrderBy and Aggregate are added for each order_by entry and each
+	// aggregate.
+	OrderBy   int
+	Aggregate int
+}
+
+// DefaultCostModel returns a CostModel where plain comparisons weigh 1,
+// pattern and JSON operators more, and joins through relations dominate.
+func DefaultCostModel() CostModel {
+	return CostModel{
+		Operators: map[string]int{
+			"_like":         2,
+			"_nlike":        2,
+			"_ilike":        3,
+			"_nilike":       3,
+			"_similar":      4,
+			"_nsimilar":     4,
+			"_regex":        5,
+			"_nregex":       5,
+			"_iregex":       5,
+			"_niregex":      5,
+			"_contains":     3,
+			"_contained_in": 3,
+			"_has_key":      2,
+			"_has_keys_any": 3,
+			"_has_keys_all": 3,
+		},
+		DefaultOperator: 1,
+		Relation:        5,
+		Depth:           2,
+		Group:           1,
+		ListItem:        1,
+		OrderBy:         1,
+		Aggregate:       2,
+	}
+}
+
+// Cost returns the cost of f under m: the sum of the weights of its
+// comparisons, relations, explicit logical groups, list operand elements,
+// order_by entries and aggregates. Limit and offset are free. Gateways can
+// compare it against a budget to reject expensive filters before running
+// them.
+func (f *Filter) Cost(m CostModel) int {
+	cost := len(f.OrderBy)*m.OrderBy + len(f.Aggregates)*m.Aggregate
+	Walk(f.Where, func(node WhereNode, path []string) bool {
+		switch n := node.(type) {
+		case *Relation:
+			cost += m.Relation
+		case *LogicalGroup:
+			if !n.Implicit {
+				cost += m.Group
+			}
+		case *Comparison:
+			weight, ok := m.Operators[n.Operator]
+			if !ok {
+				weight = m.DefaultOperator
+			}
+			cost += weight + len(path)*m.Depth
+			if list, ok := n.Value.([]any); ok {
+				cost += len(list) * m.ListItem
+			}
+		}
+		return true
+	})
+	return cost
+}
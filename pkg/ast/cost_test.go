@@ -0,0 +1,64 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCost(t *testing.T) {
+	model := CostModel{
+		Operators:       map[string]int{"_regex": 10},
+		DefaultOperator: 1,
+		Relation:        100,
+		Depth:           1000,
+		ListItem:        10000,
+		OrderBy:         100000,
+		Aggregate:       1000000,
+		Group:           10000000,
+	}
+	nested := strings.Repeat(`{"_and":[`, 2000) + `{"a":{"_eq":1}}` + strings.Repeat(`]}`, 2000)
+
+	tests := []struct {
+		name   string
+		filter string
+		want   int
+	}{
+		{name: "empty", filter: `{}`, want: 0},
+		{name: "limit and offset are free", filter: `{"limit":10,"offset":5}`, want: 0},
+		{name: "comparison", filter: `{"where":{"a":{"_eq":1}}}`, want: 1},
+		{name: "operator weight", filter: `{"where":{"a":{"_regex":"x","_eq":1}}}`, want: 11},
+		{name: "explicit groups", filter: `{"where":{"_or":[{"a":{"_eq":1}},{"_not":{"b":{"_eq":2}}}],"_and":[]}}`, want: 30000002},
+		{name: "nested groups", filter: `{"where":` + nested + `}`, want: 2000*model.Group + 1},
+		{name: "list items", filter: `{"where":{"a":{"_in":[1,2,3]},"b":{"_nin":[]}}}`, want: 30002},
+		{name: "relations and depth", filter: `{"where":{"user":{"posts":{"title":{"_eq":"x"}}},"tags":{}}}`, want: 300 + 2001},
+		{name: "order_by and aggregates", filter: `{"order_by":[{"a":"asc"},{"user":{"name":"desc"}}],"aggregate":{"count":"*"}}`, want: 1200000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(tt.filter)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := f.Cost(model); got != tt.want {
+				t.Errorf("Cost() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultCostModel(t *testing.T) {
+	cheap, err := Parse(`{"where":{"a":{"_eq":1}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expensive, err := Parse(`{"where":{"user":{"posts":{"title":{"_iregex":"x"},"id":{"_in":[1,2,3,4,5]}}}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := DefaultCostModel()
+	if c, e := cheap.Cost(m), expensive.Cost(m); c >= e {
+		t.Errorf("Cost() of cheap filter = %d, want less than %d", c, e)
+	}
+}
@@ -0,0 +1,150 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// object is a decoded JSON object that keeps its members in document order.
+type object []member
+
+type member struct {
+	key   string
+	value any
+}
+
+// decode decodes data into string, json.Number, bool, nil, []any and object
+// values. root is the path of the document, used in errors, which are
+// always *Error values. Documents with more than depth levels of objects
+// and arrays are rejected with ErrMaxDepth.
+func decode(data []byte, root string, depth int) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	value, err := decodeValue(dec, root, depth)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		var astErr *Error
+		if errors.As(err, &astErr) {
+			return nil, err
+		}
+		return nil, &Error{Err: err}
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errorf(root, nil, "unexpected data after top-level value")
+	}
+	return value, nil
+}
+
+// decodeValue decodes the next value of dec, which may contain depth more
+// levels of objects and arrays. path is the location of the value, used in
+// errors.
+func decodeValue(dec *json.Decoder, path string, depth int) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+	if depth == 0 {
+		return nil, &Error{Path: path, Err: ErrMaxDepth}
+	}
+
+	switch delim {
+	case '{':
+		obj := object{}
+		seen := make(map[string]bool)
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := keyTok.(string)
+			if seen[key] {
+				return nil, errorf(path, ErrDuplicateKey, "%q", key)
+			}
+			seen[key] = true
+
+			p := key
+			if path != "" {
+				p = path + "." + key
+			}
+			value, err := decodeValue(dec, p, depth-1)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, member{key: key, value: value})
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	case '[':
+		arr := []any{}
+		for i := 0; dec.More(); i++ {
+			value, err := decodeValue(dec, fmt.Sprintf("%s[%d]", path, i), depth-1)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return arr, nil
+	default:
+		return nil, fmt.Errorf("unexpected delimiter %q", delim)
+	}
+}
+
+// MarshalJSON encodes o with its members in order.
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, mem := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(mem.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(mem.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// plain converts ordered objects nested in value into map[string]any.
+func plain(value any) any {
+	switch v := value.(type) {
+	case object:
+		m := make(map[string]any, len(v))
+		for _, mem := range v {
+			m[mem.key] = plain(mem.value)
+		}
+		return m
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = plain(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
@@ -0,0 +1,66 @@
+package ast
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidNode is reported for a value of the wrong shape, e.g. a
+	// field whose value is not an object or an _or that is not an array.
+	ErrInvalidNode = errors.New("invalid node")
+	// ErrDuplicateKey is reported for an object that has the same key
+	// twice.
+	ErrDuplicateKey = errors.New("duplicate key")
+	// ErrOperatorWithoutField is reported when a comparison operator
+	// appears where a field or logical operator is expected, e.g.
+	// {"where": {"_eq": 1}}.
+	ErrOperatorWithoutField = errors.New("operator used without a field")
+	// ErrInvalidDirection is reported for an unknown order_by direction.
+	ErrInvalidDirection = errors.New("invalid direction")
+	// ErrInvalidCount is reported for a limit or offset that is not a
+	// non-negative integer.
+	ErrInvalidCount = errors.New("invalid count")
+	// ErrUnsupportedValue is reported by ParseMap for Go values that have
+	// no JSON equivalent, e.g. channels or NaN.
+	ErrUnsupportedValue = errors.New("unsupported value")
+)
+
+// Error is returned for a filter document that cannot be parsed, encoded or
+// validated. Use errors.As to retrieve it from errors returned by this
+// package and by packages built on it.
+type Error struct {
+	// Path is the location of the offending value, e.g. "where._or[0].age"
+	// or "order_by[1]". It is empty for errors about the whole document.
+	Path string
+	// Msg describes the problem, adding detail to Err when both are set.
+	Msg string
+	// Err is the underlying error, if any: a JSON syntax error, or one of
+	// the Err variables of this package.
+	Err error
+}
+
+func (e *Error) Error() string {
+	msg := e.Msg
+	if e.Err != nil {
+		if msg == "" {
+			msg = e.Err.Error()
+		} else {
+			msg = e.Err.Error() + ": " + msg
+		}
+	}
+	if e.Path == "" {
+		return "ast: " + msg
+	}
+	return "ast: " + e.Path + ": " + msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// errorf returns an *Error at path wrapping err, which may be nil, with a
+// formatted message.
+func errorf(path string, err error, format string, args ...any) error {
+	return &Error{Path: path, Msg: fmt.Sprintf(format, args...), Err: err}
+}
@@ -0,0 +1,59 @@
+package ast
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   string
+		wantPath string
+		wantErr  error
+	}{
+		{name: "syntax", filter: `{"where":]}`},
+		{name: "truncated", filter: `{"where":`, wantErr: io.ErrUnexpectedEOF},
+		{name: "not an object", filter: `[]`},
+		{name: "duplicate key", filter: `{"where":{"_or":[{"a":{"_eq":1,"_eq":2}}]}}`, wantPath: "where._or[0].a"},
+		{name: "operator without field", filter: `{"where":{"_or":[{"_eq":1}]}}`, wantPath: "where._or[0]._eq", wantErr: ErrOperatorWithoutField},
+		{name: "invalid node", filter: `{"where":{"user":{"name":1}}}`, wantPath: "where.user.name"},
+		{name: "order_by", filter: `{"order_by":[{"a":"asc"},{"b":"up"}]}`, wantPath: "order_by[1].b"},
+		{name: "max depth", filter: `{"where":{"_or":[{"a":{"_eq":1}}]}}`, wantPath: "where._or[0].a", wantErr: ErrMaxDepth},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.filter, MaxDepth(4))
+			var astErr *Error
+			if !errors.As(err, &astErr) {
+				t.Fatalf("Parse() error = %v, want *Error", err)
+			}
+			if astErr.Path != tt.wantPath {
+				t.Errorf("Path = %q, want %q", astErr.Path, tt.wantPath)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Parse() error = %v, want errors.Is %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	tests := []struct {
+		err  *Error
+		want string
+	}{
+		{err: &Error{Msg: "filter must be an object"}, want: "ast: filter must be an object"},
+		{err: &Error{Path: "limit", Msg: "expected integer"}, want: "ast: limit: expected integer"},
+		{err: &Error{Path: "where._eq", Err: ErrOperatorWithoutField}, want: "ast: where._eq: operator used without a field"},
+		{err: &Error{Path: "where.a", Msg: "expected object", Err: ErrInvalidNode}, want: "ast: where.a: invalid node: expected object"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
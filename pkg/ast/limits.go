@@ -0,0 +1,109 @@
+package ast
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	// ErrAggregateNotAllowed is reported by Limits.Check for an aggregate
+	// function missing from Limits.Aggregates.
+	ErrAggregateNotAllowed = errors.New("aggregate function not allowed")
+	// ErrUnknownRole is reported by Quotas.Check for a role without limits.
+	ErrUnknownRole = errors.New("unknown role")
+)
+
+// Limits bounds the size of a filter. Zero fields are not enforced.
+type Limits struct {
+	// MaxConditions is the maximum number of comparisons.
+	MaxConditions int
+	// MaxParams is the maximum number of values bound as query parameters:
+	// one for each comparison operand, one for each element of a list
+	// operand and none for _is_null. Limit and offset are not counted.
+	MaxParams int
+	// MaxOrBranches is the maximum number of branches of all _or groups
+	// together.
+	MaxOrBranches int
+	// MaxListSize is the maximum number of elements of a list operand,
+	// e.g. of _in.
+	MaxListSize int
+	// Aggregates lists the allowed aggregate functions. A nil list allows
+	// every function and an empty one none.
+	Aggregates []string
+}
+
+// LimitError is returned by Limits.Check for a filter that exceeds a limit.
+type LimitError struct {
+	// Limit is the name of the exceeded Limits field, e.g. "MaxParams".
+	Limit  string
+	Max    int
+	Actual int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("ast: %s exceeded: %d, maximum %d", e.Limit, e.Actual, e.Max)
+}
+
+// Check reports the first limit f exceeds as a *LimitError, or an error
+// wrapping ErrAggregateNotAllowed for a disallowed aggregate.
+func (l Limits) Check(f *Filter) error {
+	var conditions, params, branches, listSize int
+	Walk(f.Where, func(node WhereNode, _ []string) bool {
+		switch n := node.(type) {
+		case *LogicalGroup:
+			if n.Op == Or {
+				branches += len(n.Children)
+			}
+		case *Comparison:
+			conditions++
+			switch value := n.Value.(type) {
+			case []any:
+				params += len(value)
+				listSize = max(listSize, len(value))
+			default:
+				if n.Operator != "_is_null" {
+					params++
+				}
+			}
+		}
+		return true
+	})
+
+	for _, limit := range []struct {
+		name        string
+		max, actual int
+	}{
+		{"MaxConditions", l.MaxConditions, conditions},
+		{"MaxParams", l.MaxParams, params},
+		{"MaxOrBranches", l.MaxOrBranches, branches},
+		{"MaxListSize", l.MaxListSize, listSize},
+	} {
+		if limit.max > 0 && limit.actual > limit.max {
+			return &LimitError{Limit: limit.name, Max: limit.max, Actual: limit.actual}
+		}
+	}
+
+	if l.Aggregates != nil {
+		for _, agg := range f.Aggregates {
+			if !slices.Contains(l.Aggregates, agg.Function) {
+				return &Error{Path: "aggregate." + agg.Function, Err: ErrAggregateNotAllowed}
+			}
+		}
+	}
+	return nil
+}
+
+// Quotas maps roles to the Limits that apply to their filters, e.g. to
+// restrict free-tier API keys to simple filters.
+type Quotas map[string]Limits
+
+// Check checks f against the limits of role. Roles without an entry are
+// rejected with an error wrapping ErrUnknownRole.
+func (q Quotas) Check(role string, f *Filter) error {
+	limits, ok := q[role]
+	if !ok {
+		return fmt.Errorf("ast: %w %q", ErrUnknownRole, role)
+	}
+	return limits.Check(f)
+}
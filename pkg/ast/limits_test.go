@@ -0,0 +1,106 @@
+package ast
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    string
+		limits    Limits
+		wantLimit string
+	}{
+		{name: "no limits", filter: `{"where":{"a":{"_in":[1,2,3]},"b":{"_eq":1}}}`},
+		{name: "within limits", filter: `{"where":{"a":{"_in":[1,2,3]},"b":{"_eq":1}}}`, limits: Limits{MaxConditions: 2, MaxParams: 4}},
+		{name: "_is_null binds nothing", filter: `{"where":{"a":{"_is_null":true},"b":{"_eq":1}}}`, limits: Limits{MaxParams: 1}},
+		{name: "limit and offset are not counted", filter: `{"where":{"a":{"_eq":1}},"limit":10,"offset":5}`, limits: Limits{MaxParams: 1}},
+		{
+			name:      "too many conditions",
+			filter:    `{"where":{"_or":[{"a":{"_eq":1}},{"user":{"b":{"_eq":2}}},{"_not":{"c":{"_eq":3}}}]}}`,
+			limits:    Limits{MaxConditions: 2},
+			wantLimit: "MaxConditions",
+		},
+		{
+			name:      "too many or branches",
+			filter:    `{"where":{"_or":[{"a":{"_eq":1}},{"_or":[{"b":{"_eq":2}},{"c":{"_eq":3}}]}]}}`,
+			limits:    Limits{MaxOrBranches: 3},
+			wantLimit: "MaxOrBranches",
+		},
+		{
+			name:      "list too large",
+			filter:    `{"where":{"a":{"_in":[1,2]},"b":{"_nin":[1,2,3]}}}`,
+			limits:    Limits{MaxListSize: 2},
+			wantLimit: "MaxListSize",
+		},
+		{
+			name:   "allowed aggregates",
+			filter: `{"aggregate":{"count":"*","sum":"a"}}`,
+			limits: Limits{Aggregates: []string{"count", "sum"}},
+		},
+		{
+			name:      "too many params",
+			filter:    `{"where":{"a":{"_in":[1,2,3]},"b":{"_eq":1}}}`,
+			limits:    Limits{MaxConditions: 10, MaxParams: 3},
+			wantLimit: "MaxParams",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(tt.filter)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			err = tt.limits.Check(f)
+			if tt.wantLimit == "" {
+				if err != nil {
+					t.Errorf("Check() error = %v, want nil", err)
+				}
+				return
+			}
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("Check() error = %v, want *LimitError", err)
+			}
+			if limitErr.Limit != tt.wantLimit {
+				t.Errorf("Limit = %q, want %q", limitErr.Limit, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestLimitsAggregates(t *testing.T) {
+	f, err := Parse(`{"aggregate":{"count":"*","percentile_cont":{"field":"a","percentile":0.5}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, allowed := range [][]string{{"count"}, {}} {
+		if err := (Limits{Aggregates: allowed}).Check(f); !errors.Is(err, ErrAggregateNotAllowed) {
+			t.Errorf("Check() with aggregates %v error = %v, want ErrAggregateNotAllowed", allowed, err)
+		}
+	}
+}
+
+func TestQuotas(t *testing.T) {
+	quotas := Quotas{
+		"free":     {MaxConditions: 1, Aggregates: []string{}},
+		"internal": {},
+	}
+	f, err := Parse(`{"where":{"a":{"_eq":1},"b":{"_eq":2}},"aggregate":{"count":"*"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := quotas.Check("internal", f); err != nil {
+		t.Errorf("Check(internal) error = %v, want nil", err)
+	}
+	var limitErr *LimitError
+	if err := quotas.Check("free", f); !errors.As(err, &limitErr) || limitErr.Limit != "MaxConditions" {
+		t.Errorf("Check(free) error = %v, want MaxConditions *LimitError", err)
+	}
+	if err := quotas.Check("guest", f); !errors.Is(err, ErrUnknownRole) {
+		t.Errorf("Check(guest) error = %v, want ErrUnknownRole", err)
+	}
+}
@@ -0,0 +1,251 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// JSON returns f as a filter document string.
+func (f *Filter) JSON() (string, error) {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// MarshalJSON encodes f as a filter document. Sections are written in the
+// order where, order_by, limit, offset, aggregate, followed by Extra sorted
+// by key. order_by is always written in list form.
+func (f *Filter) MarshalJSON() ([]byte, error) {
+	doc := object{}
+	if f.Where != nil {
+		where, err := nodeValue(f.Where)
+		if err != nil {
+			return nil, err
+		}
+		doc = append(doc, member{key: "where", value: where})
+	}
+	if len(f.OrderBy) > 0 {
+		entries := make([]any, 0, len(f.OrderBy))
+		for i, entry := range f.OrderBy {
+			if len(entry.Path) == 0 {
+				return nil, errorf(fmt.Sprintf("order_by[%d]", i), ErrInvalidNode, "empty path")
+			}
+			var value any = entry.Direction
+			for j := len(entry.Path) - 1; j >= 0; j-- {
+				value = object{{key: entry.Path[j], value: value}}
+			}
+			entries = append(entries, value)
+		}
+		doc = append(doc, member{key: "order_by", value: entries})
+	}
+	if f.Limit != nil {
+		doc = append(doc, member{key: "limit", value: *f.Limit})
+	}
+	if f.Offset != nil {
+		doc = append(doc, member{key: "offset", value: *f.Offset})
+	}
+	if len(f.Aggregates) > 0 {
+		aggregates := make(object, 0, len(f.Aggregates))
+		for _, agg := range f.Aggregates {
+			aggregates = append(aggregates, member{key: agg.Function, value: aggregateValue(agg)})
+		}
+		doc = append(doc, member{key: "aggregate", value: aggregates})
+	}
+
+	keys := make([]string, 0, len(f.Extra))
+	for key := range f.Extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		doc = append(doc, member{key: key, value: f.Extra[key]})
+	}
+
+	return json.Marshal(doc)
+}
+
+func aggregateValue(agg Aggregate) any {
+	if len(agg.Options) == 0 {
+		switch len(agg.Fields) {
+		case 0:
+			return object{}
+		case 1:
+			return agg.Fields[0]
+		default:
+			return agg.Fields
+		}
+	}
+
+	spec := object{}
+	switch len(agg.Fields) {
+	case 0:
+	case 1:
+		spec = append(spec, member{key: "field", value: agg.Fields[0]})
+	default:
+		spec = append(spec, member{key: "fields", value: agg.Fields})
+	}
+
+	keys := make([]string, 0, len(agg.Options))
+	for key := range agg.Options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		spec = append(spec, member{key: key, value: agg.Options[key]})
+	}
+	return spec
+}
+
+// MarshalJSON encodes g as a where object.
+func (g *LogicalGroup) MarshalJSON() ([]byte, error) {
+	return marshalNode(g)
+}
+
+// MarshalJSON encodes c as a where object.
+func (c *Comparison) MarshalJSON() ([]byte, error) {
+	return marshalNode(c)
+}
+
+// MarshalJSON encodes r as a where object.
+func (r *Relation) MarshalJSON() ([]byte, error) {
+	return marshalNode(r)
+}
+
+func marshalNode(node WhereNode) ([]byte, error) {
+	value, err := nodeValue(node)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// nodeValue converts node into its ordered JSON object form.
+func nodeValue(node WhereNode) (object, error) {
+	switch n := node.(type) {
+	case *Comparison:
+		operators := append(object{{key: n.Operator, value: n.Value}}, metaMembers(n.Meta)...)
+		return object{{key: n.Field, value: operators}}, nil
+	case *Relation:
+		where := object{}
+		if n.Where != nil {
+			var err error
+			if where, err = nodeValue(n.Where); err != nil {
+				return nil, err
+			}
+		}
+		return object{{key: n.Field, value: where}}, nil
+	case *LogicalGroup:
+		return groupValue(n)
+	default:
+		return nil, errorf("", ErrInvalidNode, "unsupported node %T", node)
+	}
+}
+
+func groupValue(g *LogicalGroup) (object, error) {
+	value, err := groupMembers(g)
+	if err != nil {
+		return nil, err
+	}
+	return append(value, metaMembers(g.Meta)...), nil
+}
+
+func groupMembers(g *LogicalGroup) (object, error) {
+	children := make([]object, 0, len(g.Children))
+	for _, child := range g.Children {
+		value, err := nodeValue(child)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, value)
+	}
+
+	switch g.Op {
+	case Not:
+		if len(children) != 1 {
+			return nil, errorf("", ErrInvalidNode, "_not group must have exactly one child, got %d", len(children))
+		}
+		return object{{key: string(Not), value: children[0]}}, nil
+	case And:
+		if g.Implicit {
+			if merged, ok := mergeObjects(children); ok {
+				return merged, nil
+			}
+		}
+	case Or:
+	default:
+		return nil, errorf("", ErrInvalidNode, "unsupported logical operator %q", g.Op)
+	}
+
+	items := make([]any, len(children))
+	for i, child := range children {
+		items[i] = child
+	}
+	return object{{key: string(g.Op), value: items}}, nil
+}
+
+// mergeObjects merges the members of objs into a single object, combining
+// operator objects of the same column. It reports false when two members
+// cannot be combined without changing the meaning of the filter.
+func mergeObjects(objs []object) (object, bool) {
+	merged := object{}
+	index := make(map[string]int)
+	for _, obj := range objs {
+		for _, m := range obj {
+			i, ok := index[m.key]
+			if !ok {
+				index[m.key] = len(merged)
+				merged = append(merged, m)
+				continue
+			}
+			combined, ok := mergeOperators(merged[i].value, m.value)
+			if !ok {
+				return nil, false
+			}
+			merged[i].value = combined
+		}
+	}
+	return merged, true
+}
+
+func mergeOperators(a, b any) (object, bool) {
+	left, ok := a.(object)
+	if !ok {
+		return nil, false
+	}
+	right, ok := b.(object)
+	if !ok || len(left) == 0 || len(right) == 0 {
+		return nil, false
+	}
+
+	seen := make(map[string]bool, len(left))
+	for _, m := range left {
+		if !isOperator(m.key) && !isMeta(m.key) {
+			return nil, false
+		}
+		seen[m.key] = true
+	}
+	for _, m := range right {
+		if (!isOperator(m.key) && !isMeta(m.key)) || seen[m.key] {
+			return nil, false
+		}
+	}
+	return append(left[:len(left):len(left)], right...), true
+}
+
+// metaMembers returns the annotations in meta sorted by key.
+func metaMembers(meta map[string]any) object {
+	keys := make([]string, 0, len(meta))
+	for key := range meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	members := make(object, len(keys))
+	for i, key := range keys {
+		members[i] = member{key: key, value: meta[key]}
+	}
+	return members
+}
@@ -0,0 +1,139 @@
+package ast
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMarshalRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		want   string
+	}{
+		{name: "empty", filter: `{}`, want: `{}`},
+		{
+			name:   "implicit and is written as one object",
+			filter: `{"where":{"age":{"_gt":1,"_lt":5},"name":{"_eq":"x"}}}`,
+			want:   `{"where":{"age":{"_gt":1,"_lt":5},"name":{"_eq":"x"}}}`,
+		},
+		{
+			name:   "explicit groups are kept",
+			filter: `{"where":{"_and":[{"a":{"_eq":1}}],"_or":[],"_not":{"b":{"_is_null":true}}}}`,
+			want:   `{"where":{"_and":[{"a":{"_eq":1}}],"_or":[],"_not":{"b":{"_is_null":true}}}}`,
+		},
+		{
+			name:   "relations and empty objects",
+			filter: `{"where":{"user":{"profile":{"_or":[{"name":{"_eq":"x"}},{}]}},"posts":{}}}`,
+			want:   `{"where":{"user":{"profile":{"_or":[{"name":{"_eq":"x"}},{}]}},"posts":{}}}`,
+		},
+		{
+			name:   "numbers keep their precision",
+			filter: `{"where":{"amount":{"_eq":12345678901234567890.123456789}}}`,
+			want:   `{"where":{"amount":{"_eq":12345678901234567890.123456789}}}`,
+		},
+		{
+			name:   "order_by is written in list form",
+			filter: `{"order_by":{"user":{"name":"desc"},"id":"asc"}}`,
+			want:   `{"order_by":[{"user":{"name":"desc"}},{"id":"asc"}]}`,
+		},
+		{
+			name:   "sections are written in canonical order",
+			filter: `{"zeta":[1],"aggregate":{"count":"*"},"offset":2,"limit":1,"alpha":{"b":1,"a":2},"where":{"a":{"_eq":1}}}`,
+			want:   `{"where":{"a":{"_eq":1}},"limit":1,"offset":2,"aggregate":{"count":"*"},"alpha":{"b":1,"a":2},"zeta":[1]}`,
+		},
+		{
+			name:   "aggregate forms",
+			filter: `{"aggregate":{"count":"*","corr":["y","x"],"percentile_cont":{"percentile":0.5,"field":"p"},"sum":{"fields":["a","b"],"distinct":true},"x":{}}}`,
+			want:   `{"aggregate":{"count":"*","corr":["y","x"],"percentile_cont":{"field":"p","percentile":0.5},"sum":{"fields":["a","b"],"distinct":true},"x":{}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(tt.filter)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got, err := f.JSON()
+			if err != nil {
+				t.Fatalf("JSON() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("JSON() = %s, want %s", got, tt.want)
+			}
+
+			again, err := Parse(got)
+			if err != nil {
+				t.Fatalf("Parse() of output error = %v", err)
+			}
+			if second, _ := again.JSON(); second != got {
+				t.Errorf("second round trip = %s, want %s", second, got)
+			}
+		})
+	}
+}
+
+func TestMarshalNodes(t *testing.T) {
+	tests := []struct {
+		name string
+		node WhereNode
+		want string
+	}{
+		{
+			name: "conflicting implicit members fall back to _and",
+			node: &LogicalGroup{Op: And, Implicit: true, Children: []WhereNode{
+				&Comparison{Field: "a", Operator: "_eq", Value: 1},
+				&Comparison{Field: "a", Operator: "_eq", Value: 2},
+			}},
+			want: `{"_and":[{"a":{"_eq":1}},{"a":{"_eq":2}}]}`,
+		},
+		{
+			name: "relations with the same field are not merged",
+			node: &LogicalGroup{Op: And, Implicit: true, Children: []WhereNode{
+				&Relation{Field: "posts", Where: &Comparison{Field: "a", Operator: "_eq", Value: 1}},
+				&Relation{Field: "posts", Where: &Comparison{Field: "b", Operator: "_eq", Value: 2}},
+			}},
+			want: `{"_and":[{"posts":{"a":{"_eq":1}}},{"posts":{"b":{"_eq":2}}}]}`,
+		},
+		{
+			name: "relation without conditions is not merged with a comparison",
+			node: &LogicalGroup{Op: And, Implicit: true, Children: []WhereNode{
+				&Relation{Field: "a"},
+				&Comparison{Field: "a", Operator: "_eq", Value: 1},
+			}},
+			want: `{"_and":[{"a":{}},{"a":{"_eq":1}}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.node)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter *Filter
+	}{
+		{name: "not without child", filter: &Filter{Where: &LogicalGroup{Op: Not}}},
+		{name: "unknown operator", filter: &Filter{Where: &LogicalGroup{Op: "_xor"}}},
+		{name: "empty order_by path", filter: &Filter{OrderBy: []OrderBy{{Direction: "asc"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.filter.JSON(); err == nil {
+				t.Error("JSON() error = nil, want error")
+			}
+		})
+	}
+}
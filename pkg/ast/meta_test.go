@@ -0,0 +1,111 @@
+package ast
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseMeta(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		want   *Filter
+	}{
+		{
+			name:   "comparison annotations",
+			filter: `{"where":{"age":{"_gt":18,"_comment":"adults"}}}`,
+			want: &Filter{Where: &Comparison{
+				Field: "age", Operator: "_gt", Value: json.Number("18"),
+				Meta: map[string]any{"_comment": "adults"},
+			}},
+		},
+		{
+			name:   "group annotations",
+			filter: `{"where":{"_meta":{"owner":"x"},"a":{"_eq":1}}}`,
+			want: &Filter{Where: &LogicalGroup{Op: And, Implicit: true,
+				Children: []WhereNode{&Comparison{Field: "a", Operator: "_eq", Value: json.Number("1")}},
+				Meta:     map[string]any{"_meta": map[string]any{"owner": "x"}},
+			}},
+		},
+		{
+			name:   "annotations only",
+			filter: `{"where":{"_comment":"everything"}}`,
+			want: &Filter{Where: &LogicalGroup{Op: And, Implicit: true,
+				Meta: map[string]any{"_comment": "everything"},
+			}},
+		},
+		{
+			name:   "top-level annotations",
+			filter: `{"_comment":"saved view","limit":1}`,
+			want:   &Filter{Limit: int64p(1), Extra: map[string]json.RawMessage{"_comment": json.RawMessage(`"saved view"`)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.filter)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(tt.want)
+				t.Errorf("Parse() = %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestMetaRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		filter     string
+		want       string
+		normalized string
+	}{
+		{
+			name:       "comparison",
+			filter:     `{"where":{"age":{"_gt":18,"_comment":"adults"}}}`,
+			want:       `{"where":{"age":{"_gt":18,"_comment":"adults"}}}`,
+			normalized: `{"where":{"age":{"_gt":18}}}`,
+		},
+		{
+			name:       "nested group",
+			filter:     `{"where":{"_or":[{"_meta":{"label":"l"},"a":{"_eq":1}},{"b":{"_eq":2}}]}}`,
+			want:       `{"where":{"_or":[{"a":{"_eq":1},"_meta":{"label":"l"}},{"b":{"_eq":2}}]}}`,
+			normalized: `{"where":{"_or":[{"a":{"_eq":1}},{"b":{"_eq":2}}]}}`,
+		},
+		{
+			name:       "relation",
+			filter:     `{"where":{"user":{"_comment":"c","name":{"_eq":"x"}}}}`,
+			want:       `{"where":{"user":{"name":{"_eq":"x"},"_comment":"c"}}}`,
+			normalized: `{"where":{"user":{"name":{"_eq":"x"}}}}`,
+		},
+		{
+			name:       "top level",
+			filter:     `{"_meta":{"owner":"x"},"where":{"_comment":"all"}}`,
+			want:       `{"where":{"_comment":"all"},"_meta":{"owner":"x"}}`,
+			normalized: `{}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(tt.filter)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got, err := f.JSON()
+			if err != nil {
+				t.Fatalf("JSON() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("JSON() = %s, want %s", got, tt.want)
+			}
+			if got := normalizedJSON(t, tt.filter); got != tt.normalized {
+				t.Errorf("Normalize() = %s, want %s", got, tt.normalized)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ParseMap is like Parse for a document already decoded into Go values,
+// e.g. by json.Unmarshal into map[string]any, without encoding it back to
+// JSON text.
+//
+// Maps with string keys, slices, arrays, strings, booleans, numbers,
+// json.Number, pointers to any of these, values implementing
+// json.Marshaler and nil are accepted. Map members are read in key order,
+// so an order_by given as an object is ordered by field name; use the list
+// form to control the order.
+func ParseMap(doc map[string]any, opts ...Option) (*Filter, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if doc == nil {
+		return &Filter{}, nil
+	}
+
+	value, err := native(reflect.ValueOf(doc), "", o.depth())
+	if err != nil {
+		return nil, err
+	}
+	return parseDocument(value)
+}
+
+// native converts v into the values produced by decode. path is the
+// location of v, used in errors, and depth the number of levels of maps and
+// slices it may contain.
+func native(v reflect.Value, path string, depth int) (any, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if v.Type().Implements(jsonMarshaler) && !(v.Kind() == reflect.Pointer && v.IsNil()) {
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return nil, &Error{Path: path, Err: err}
+		}
+		return decode(data, path, depth)
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return native(v.Elem(), path, depth)
+	case reflect.String:
+		if v.Type() == reflect.TypeFor[json.Number]() {
+			return json.Number(v.String()), nil
+		}
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return json.Number(strconv.FormatInt(v.Int(), 10)), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return json.Number(strconv.FormatUint(v.Uint(), 10)), nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, errorf(path, ErrUnsupportedValue, "%v", f)
+		}
+		return json.Number(strconv.FormatFloat(f, 'g', -1, v.Type().Bits())), nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, errorf(path, ErrUnsupportedValue, "map key type %s", v.Type().Key())
+		}
+		if v.IsNil() {
+			return nil, nil
+		}
+		if depth == 0 {
+			return nil, &Error{Path: path, Err: ErrMaxDepth}
+		}
+		keys := v.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return strings.Compare(a.String(), b.String())
+		})
+		obj := make(object, 0, len(keys))
+		for _, key := range keys {
+			p := key.String()
+			if path != "" {
+				p = path + "." + p
+			}
+			value, err := native(v.MapIndex(key), p, depth-1)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, member{key: key.String(), value: value})
+		}
+		return obj, nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		if depth == 0 {
+			return nil, &Error{Path: path, Err: ErrMaxDepth}
+		}
+		arr := make([]any, v.Len())
+		for i := range arr {
+			value, err := native(v.Index(i), fmt.Sprintf("%s[%d]", path, i), depth-1)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = value
+		}
+		return arr, nil
+	default:
+		return nil, errorf(path, ErrUnsupportedValue, "type %s", v.Type())
+	}
+}
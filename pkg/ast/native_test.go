@@ -0,0 +1,63 @@
+package ast
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseMap(t *testing.T) {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		doc  map[string]any
+		want string
+	}{
+		{name: "nil", doc: nil, want: `{}`},
+		{
+			name: "native values",
+			doc: map[string]any{
+				"where": map[string]any{
+					"age":  map[string]any{"_gte": 18},
+					"id":   map[string]any{"_in": []int64{1, 2}},
+					"tags": map[string]any{"_eq": json.Number("1.50")},
+				},
+				"limit": uint8(10),
+			},
+			want: `{"where":{"age":{"_gte":18},"id":{"_in":[1,2]},"tags":{"_eq":1.50}},"limit":10}`,
+		},
+		{
+			name: "marshalers",
+			doc:  map[string]any{"where": map[string]any{"created_at": map[string]any{"_gt": day}}},
+			want: `{"where":{"created_at":{"_gt":"2024-01-02T00:00:00Z"}}}`,
+		},
+		{
+			name: "order_by object in key order",
+			doc:  map[string]any{"order_by": map[string]string{"name": "asc", "id": "desc"}},
+			want: `{"order_by":[{"id":"desc"},{"name":"asc"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ParseMap(tt.doc)
+			if err != nil {
+				t.Fatalf("ParseMap() error = %v", err)
+			}
+			if got, _ := f.JSON(); got != tt.want {
+				t.Errorf("ParseMap() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	_, err := ParseMap(map[string]any{"where": map[string]any{"a": map[string]any{"_eq": make(chan int)}}})
+	if !errors.Is(err, ErrUnsupportedValue) || err.Error() != "ast: where.a._eq: unsupported value: type chan int" {
+		t.Errorf("ParseMap() error = %v", err)
+	}
+
+	nested := map[string]any{"where": map[string]any{"_not": map[string]any{"a": map[string]any{"_eq": 1}}}}
+	if _, err := ParseMap(nested, MaxDepth(3)); !errors.Is(err, ErrMaxDepth) {
+		t.Errorf("ParseMap() with MaxDepth error = %v, want ErrMaxDepth", err)
+	}
+}
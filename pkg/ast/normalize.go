@@ -0,0 +1,191 @@
+package ast
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+)
+
+// Normalize returns a canonical copy of f. Logical groups with a single
+// child are replaced by the child, nested groups of the same operator are
+// flattened, the children of _and and _or groups and the aggregates are
+// sorted, Extra sections are re-encoded with sorted keys and _meta/_comment
+// annotations are dropped. order_by is left untouched since its order is
+// significant. f is not modified.
+//
+// Equivalent filters that differ only in these respects normalize to the
+// same JSON.
+func (f *Filter) Normalize() (*Filter, error) {
+	out := &Filter{
+		OrderBy: append([]OrderBy(nil), f.OrderBy...),
+		Limit:   f.Limit,
+		Offset:  f.Offset,
+	}
+
+	if f.Where != nil {
+		where, err := normalizeNode(f.Where)
+		if err != nil {
+			return nil, err
+		}
+		if group, ok := where.(*LogicalGroup); !ok || group.Op != And || len(group.Children) > 0 {
+			out.Where = where
+		}
+	}
+
+	if len(f.Aggregates) > 0 {
+		out.Aggregates = append([]Aggregate(nil), f.Aggregates...)
+		sort.SliceStable(out.Aggregates, func(i, j int) bool {
+			return out.Aggregates[i].Function < out.Aggregates[j].Function
+		})
+	}
+
+	if len(f.Extra) > 0 {
+		out.Extra = make(map[string]json.RawMessage, len(f.Extra))
+		for key, raw := range f.Extra {
+			if isMeta(key) {
+				continue
+			}
+			dec := json.NewDecoder(bytes.NewReader(raw))
+			dec.UseNumber()
+			var value any
+			if err := dec.Decode(&value); err != nil {
+				return nil, err
+			}
+			sorted, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			out.Extra[key] = sorted
+		}
+	}
+
+	return out, nil
+}
+
+// Fingerprint returns a hash of the normalized shape of f. Comparison
+// operands are ignored, except for the number of elements of list operands
+// and the operand of _is_null, which change the generated SQL; so are the
+// limit and offset values. Filters with the same fingerprint differ only in
+// bound values.
+func (f *Filter) Fingerprint() (string, error) {
+	shape := &Filter{
+		OrderBy:    f.OrderBy,
+		Aggregates: f.Aggregates,
+		Extra:      f.Extra,
+	}
+	if f.Where != nil {
+		shape.Where = maskNode(f.Where)
+	}
+	if f.Limit != nil {
+		shape.Limit = new(int64)
+	}
+	if f.Offset != nil {
+		shape.Offset = new(int64)
+	}
+
+	normalized, err := shape.Normalize()
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(normalized)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func normalizeNode(node WhereNode) (WhereNode, error) {
+	switch n := node.(type) {
+	case *Comparison:
+		c := *n
+		c.Meta = nil
+		return &c, nil
+	case *Relation:
+		r := &Relation{Field: n.Field}
+		if n.Where != nil {
+			where, err := normalizeNode(n.Where)
+			if err != nil {
+				return nil, err
+			}
+			r.Where = where
+		}
+		return r, nil
+	case *LogicalGroup:
+		children := make([]WhereNode, 0, len(n.Children))
+		for _, child := range n.Children {
+			normalized, err := normalizeNode(child)
+			if err != nil {
+				return nil, err
+			}
+			if group, ok := normalized.(*LogicalGroup); ok && n.Op != Not && group.Op == n.Op {
+				children = append(children, group.Children...)
+				continue
+			}
+			children = append(children, normalized)
+		}
+
+		if n.Op == Not {
+			return &LogicalGroup{Op: Not, Children: children}, nil
+		}
+		if len(children) == 1 {
+			return children[0], nil
+		}
+
+		keys := make(map[WhereNode]string, len(children))
+		for _, child := range children {
+			b, err := json.Marshal(child)
+			if err != nil {
+				return nil, err
+			}
+			keys[child] = string(b)
+		}
+		sort.SliceStable(children, func(i, j int) bool {
+			return keys[children[i]] < keys[children[j]]
+		})
+
+		return &LogicalGroup{Op: n.Op, Children: children, Implicit: n.Op == And}, nil
+	default:
+		return node, nil
+	}
+}
+
+// maskNode returns a copy of node with comparison operands replaced by
+// placeholders.
+func maskNode(node WhereNode) WhereNode {
+	switch n := node.(type) {
+	case *Comparison:
+		c := *n
+		if c.Operator != "_is_null" {
+			c.Value = maskValue(c.Value)
+		}
+		return &c
+	case *Relation:
+		r := &Relation{Field: n.Field}
+		if n.Where != nil {
+			r.Where = maskNode(n.Where)
+		}
+		return r
+	case *LogicalGroup:
+		g := &LogicalGroup{Op: n.Op, Implicit: n.Implicit, Children: make([]WhereNode, len(n.Children))}
+		for i, child := range n.Children {
+			g.Children[i] = maskNode(child)
+		}
+		return g
+	default:
+		return node
+	}
+}
+
+func maskValue(value any) any {
+	if list, ok := value.([]any); ok {
+		masked := make([]any, len(list))
+		for i := range masked {
+			masked[i] = "?"
+		}
+		return masked
+	}
+	return "?"
+}
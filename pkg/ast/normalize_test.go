@@ -0,0 +1,151 @@
+package ast
+
+import "testing"
+
+func normalizedJSON(t *testing.T, filter string) string {
+	t.Helper()
+	f, err := Parse(filter)
+	if err != nil {
+		t.Fatalf("Parse(%s) error = %v", filter, err)
+	}
+	n, err := f.Normalize()
+	if err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	out, err := n.JSON()
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+	return out
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters []string
+		want    string
+	}{
+		{
+			name: "key order",
+			filters: []string{
+				`{"where":{"b":{"_eq":2},"a":{"_eq":1}}}`,
+				`{"where":{"a":{"_eq":1},"b":{"_eq":2}}}`,
+				`{"where":{"_and":[{"b":{"_eq":2}},{"a":{"_eq":1}}]}}`,
+			},
+			want: `{"where":{"a":{"_eq":1},"b":{"_eq":2}}}`,
+		},
+		{
+			name: "single child groups",
+			filters: []string{
+				`{"where":{"_and":[{"a":{"_eq":1}}]}}`,
+				`{"where":{"_or":[{"_and":[{"a":{"_eq":1}}]}]}}`,
+				`{"where":{"a":{"_eq":1}}}`,
+			},
+			want: `{"where":{"a":{"_eq":1}}}`,
+		},
+		{
+			name: "nested groups of the same operator",
+			filters: []string{
+				`{"where":{"_or":[{"a":{"_eq":1}},{"_or":[{"c":{"_eq":3}},{"b":{"_eq":2}}]}]}}`,
+				`{"where":{"_or":[{"_or":[{"a":{"_eq":1}},{"b":{"_eq":2}}]},{"c":{"_eq":3}}]}}`,
+			},
+			want: `{"where":{"_or":[{"a":{"_eq":1}},{"b":{"_eq":2}},{"c":{"_eq":3}}]}}`,
+		},
+		{
+			name: "empty where",
+			filters: []string{
+				`{}`,
+				`{"where":{}}`,
+				`{"where":{"_and":[{}]}}`,
+			},
+			want: `{}`,
+		},
+		{
+			name: "annotations",
+			filters: []string{
+				`{"_comment":"saved view","where":{"_meta":{"owner":"x"},"a":{"_eq":1,"_comment":"c"}}}`,
+				`{"where":{"a":{"_eq":1}}}`,
+			},
+			want: `{"where":{"a":{"_eq":1}}}`,
+		},
+		{
+			name: "aggregates and extra sections",
+			filters: []string{
+				`{"aggregate":{"sum":"x","count":"*"},"search":{"b":1,"a":2}}`,
+				`{"search":{"a":2,"b":1},"aggregate":{"count":"*","sum":"x"}}`,
+			},
+			want: `{"aggregate":{"count":"*","sum":"x"},"search":{"a":2,"b":1}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, filter := range tt.filters {
+				if got := normalizedJSON(t, filter); got != tt.want {
+					t.Errorf("Normalize(%s) = %s, want %s", filter, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeKeepsDistinctFilters(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{name: "order_by order", a: `{"order_by":[{"a":"asc"},{"b":"asc"}]}`, b: `{"order_by":[{"b":"asc"},{"a":"asc"}]}`},
+		{name: "and vs or", a: `{"where":{"_and":[{"a":{"_eq":1}},{"b":{"_eq":2}}]}}`, b: `{"where":{"_or":[{"a":{"_eq":1}},{"b":{"_eq":2}}]}}`},
+		{name: "not is not flattened", a: `{"where":{"_not":{"_not":{"a":{"_eq":1}}}}}`, b: `{"where":{"a":{"_eq":1}}}`},
+		{name: "relation vs field", a: `{"where":{"a":{"b":{"_eq":1}}}}`, b: `{"where":{"b":{"_eq":1}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if a, b := normalizedJSON(t, tt.a), normalizedJSON(t, tt.b); a == b {
+				t.Errorf("Normalize() of %s and %s are both %s", tt.a, tt.b, a)
+			}
+		})
+	}
+}
+
+func fingerprint(t *testing.T, filter string) string {
+	t.Helper()
+	f, err := Parse(filter)
+	if err != nil {
+		t.Fatalf("Parse(%s) error = %v", filter, err)
+	}
+	fp, err := f.Fingerprint()
+	if err != nil {
+		t.Fatalf("Fingerprint() error = %v", err)
+	}
+	return fp
+}
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		same bool
+	}{
+		{name: "different values", a: `{"where":{"a":{"_eq":1}}}`, b: `{"where":{"a":{"_eq":"x"}}}`, same: true},
+		{name: "different key order", a: `{"where":{"a":{"_eq":1},"b":{"_gt":2}}}`, b: `{"where":{"b":{"_gt":5},"a":{"_eq":3}}}`, same: true},
+		{name: "same list arity", a: `{"where":{"a":{"_in":[1,2]}}}`, b: `{"where":{"a":{"_in":[3,4]}}}`, same: true},
+		{name: "limit and offset values", a: `{"limit":10,"offset":0}`, b: `{"limit":20,"offset":40}`, same: true},
+		{name: "different list arity", a: `{"where":{"a":{"_in":[1,2]}}}`, b: `{"where":{"a":{"_in":[1,2,3]}}}`},
+		{name: "different _is_null", a: `{"where":{"a":{"_is_null":true}}}`, b: `{"where":{"a":{"_is_null":false}}}`},
+		{name: "different operator", a: `{"where":{"a":{"_eq":1}}}`, b: `{"where":{"a":{"_neq":1}}}`},
+		{name: "different field", a: `{"where":{"a":{"_eq":1}}}`, b: `{"where":{"b":{"_eq":1}}}`},
+		{name: "limit present", a: `{}`, b: `{"limit":10}`},
+		{name: "order_by direction", a: `{"order_by":{"a":"asc"}}`, b: `{"order_by":{"a":"desc"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := fingerprint(t, tt.a), fingerprint(t, tt.b)
+			if (a == b) != tt.same {
+				t.Errorf("Fingerprint(%s) == Fingerprint(%s) is %v, want %v", tt.a, tt.b, a == b, tt.same)
+			}
+		})
+	}
+}
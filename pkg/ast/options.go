@@ -0,0 +1,37 @@
+package ast
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrMaxDepth is reported for documents nested deeper than the limit set
+// with MaxDepth.
+var ErrMaxDepth = errors.New("maximum nesting depth exceeded")
+
+// Option configures Parse.
+type Option func(*options)
+
+type options struct {
+	maxDepth int
+}
+
+// MaxDepth rejects documents with more than n nested JSON objects and
+// arrays, e.g. {"where": {"a": {"_eq": 1}}} has a depth of 3. The limit is
+// checked while decoding, so adversarial payloads with deeply nested
+// _and/_or groups are rejected before any of their nodes is built. Zero
+// means no limit, which is the default.
+func MaxDepth(n int) Option {
+	return func(o *options) {
+		o.maxDepth = n
+	}
+}
+
+// depth returns the number of nested objects and arrays a document may
+// contain.
+func (o options) depth() int {
+	if o.maxDepth <= 0 {
+		return math.MaxInt
+	}
+	return o.maxDepth
+}
@@ -0,0 +1,34 @@
+package ast
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMaxDepth(t *testing.T) {
+	nested := func(levels int) string {
+		return `{"where":` + strings.Repeat(`{"_not":`, levels) + `{"a":{"_eq":1}}` + strings.Repeat(`}`, levels) + `}`
+	}
+
+	tests := []struct {
+		name    string
+		filter  string
+		depth   int
+		wantErr bool
+	}{
+		{name: "no limit", filter: nested(1000)},
+		{name: "at the limit", filter: `{"where":{"a":{"_eq":1}}}`, depth: 3},
+		{name: "lists count as a level", filter: `{"where":{"a":{"_in":[1]}}}`, depth: 3, wantErr: true},
+		{name: "above the limit", filter: nested(10), depth: 8, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.filter, MaxDepth(tt.depth))
+			if got := errors.Is(err, ErrMaxDepth); got != tt.wantErr {
+				t.Errorf("Parse() error = %v, want ErrMaxDepth: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
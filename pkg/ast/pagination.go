@@ -0,0 +1,45 @@
+package ast
+
+import "errors"
+
+// ErrLimitTooLarge is reported by LimitPolicy.Apply for a limit above the
+// maximum when the policy rejects them.
+var ErrLimitTooLarge = errors.New("limit too large")
+
+// LimitPolicy enforces a default and a maximum limit, e.g. for public APIs
+// exposed directly to browsers.
+type LimitPolicy struct {
+	// Default is the limit of filters without one. When it is zero they
+	// get Max instead, so a missing limit cannot bypass the cap.
+	Default int64
+	// Max caps the limit. Zero means no cap.
+	Max int64
+	// Reject makes limits above Max an error wrapping ErrLimitTooLarge
+	// instead of clamping them to Max.
+	Reject bool
+}
+
+// Apply returns f with the policy applied. f is not modified.
+func (p LimitPolicy) Apply(f *Filter) (*Filter, error) {
+	var limit int64
+	switch {
+	case f.Limit != nil:
+		limit = *f.Limit
+		if p.Max > 0 && limit > p.Max && p.Reject {
+			return nil, errorf("limit", ErrLimitTooLarge, "%d, maximum %d", limit, p.Max)
+		}
+	case p.Default > 0:
+		limit = p.Default
+	case p.Max > 0:
+		limit = p.Max
+	default:
+		return f, nil
+	}
+	if p.Max > 0 && limit > p.Max {
+		limit = p.Max
+	}
+
+	out := *f
+	out.Limit = &limit
+	return &out, nil
+}
@@ -0,0 +1,59 @@
+package ast
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLimitPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		policy LimitPolicy
+		want   string
+	}{
+		{name: "no policy", filter: `{}`, want: `{}`},
+		{name: "default", filter: `{}`, policy: LimitPolicy{Default: 20, Max: 100}, want: `{"limit":20}`},
+		{name: "default above max", filter: `{}`, policy: LimitPolicy{Default: 200, Max: 100}, want: `{"limit":100}`},
+		{name: "max without default", filter: `{"offset":5}`, policy: LimitPolicy{Max: 100}, want: `{"limit":100,"offset":5}`},
+		{name: "client limit kept", filter: `{"limit":50}`, policy: LimitPolicy{Default: 20, Max: 100}, want: `{"limit":50}`},
+		{name: "zero limit kept", filter: `{"limit":0}`, policy: LimitPolicy{Default: 20, Max: 100}, want: `{"limit":0}`},
+		{name: "clamped", filter: `{"limit":500}`, policy: LimitPolicy{Max: 100}, want: `{"limit":100}`},
+		{name: "at max with reject", filter: `{"limit":100}`, policy: LimitPolicy{Max: 100, Reject: true}, want: `{"limit":100}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(tt.filter)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			out, err := tt.policy.Apply(f)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			got, err := out.JSON()
+			if err != nil {
+				t.Fatalf("JSON() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Apply() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitPolicyReject(t *testing.T) {
+	f, err := Parse(`{"limit":500}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = LimitPolicy{Max: 100, Reject: true}.Apply(f)
+	var astErr *Error
+	if !errors.As(err, &astErr) || astErr.Path != "limit" || !errors.Is(err, ErrLimitTooLarge) {
+		t.Errorf("Apply() error = %v, want *Error at limit wrapping ErrLimitTooLarge", err)
+	}
+	if *f.Limit != 500 {
+		t.Errorf("Apply() modified its input")
+	}
+}
@@ -0,0 +1,363 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// directions are the accepted order_by directions.
+var directions = map[string]bool{
+	"asc":              true,
+	"desc":             true,
+	"asc_nulls_first":  true,
+	"asc_nulls_last":   true,
+	"desc_nulls_first": true,
+	"desc_nulls_last":  true,
+}
+
+// Parse parses a filter document. An empty or null document yields an empty
+// Filter.
+func Parse(filter string, opts ...Option) (*Filter, error) {
+	return ParseBytes([]byte(filter), opts...)
+}
+
+// ParseBytes is like Parse for a document held in a byte slice, e.g. a
+// request body, sparing the conversion to a string.
+func ParseBytes(data []byte, opts ...Option) (*Filter, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		return &Filter{}, nil
+	}
+	doc, err := decode(data, "", o.depth())
+	if err != nil {
+		return nil, err
+	}
+	return parseDocument(doc)
+}
+
+// parseDocument parses a decoded filter document.
+func parseDocument(doc any) (*Filter, error) {
+	f := &Filter{}
+	if doc == nil {
+		return f, nil
+	}
+	obj, ok := doc.(object)
+	if !ok {
+		return nil, errorf("", ErrInvalidNode, "filter must be an object")
+	}
+
+	var err error
+	for _, m := range obj {
+		if m.value == nil {
+			continue
+		}
+		switch m.key {
+		case "where":
+			f.Where, err = parseWhereSection(m.value)
+		case "order_by":
+			f.OrderBy, err = parseOrderBy(m.value, "order_by")
+		case "limit":
+			f.Limit, err = parseCount(m.value, "limit")
+		case "offset":
+			f.Offset, err = parseCount(m.value, "offset")
+		case "aggregate":
+			f.Aggregates, err = parseAggregates(m.value, "aggregate")
+		default:
+			var raw []byte
+			raw, err = json.Marshal(m.value)
+			if f.Extra == nil {
+				f.Extra = make(map[string]json.RawMessage)
+			}
+			f.Extra[m.key] = raw
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// ParseWhere parses a where object on its own, e.g. {"age": {"_gt": 18}}.
+// An empty, null or {} object yields a nil node.
+func ParseWhere(where string, opts ...Option) (WhereNode, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if strings.TrimSpace(where) == "" {
+		return nil, nil
+	}
+	doc, err := decode([]byte(where), "where", o.depth())
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, nil
+	}
+	return parseWhereSection(doc)
+}
+
+// parseWhereSection parses the value of a where section. A where object
+// without conditions or annotations yields a nil node.
+func parseWhereSection(value any) (WhereNode, error) {
+	node, err := parseWhere(value, "where")
+	if err != nil {
+		return nil, err
+	}
+	if group, ok := node.(*LogicalGroup); ok && group.Implicit && len(group.Children) == 0 && len(group.Meta) == 0 {
+		return nil, nil
+	}
+	return node, nil
+}
+
+func isLogical(key string) bool {
+	return key == string(And) || key == string(Or) || key == string(Not)
+}
+
+// isMeta reports whether key is an annotation key, which is kept in the
+// tree but has no effect on the filter.
+func isMeta(key string) bool {
+	return key == "_meta" || key == "_comment"
+}
+
+func isOperator(key string) bool {
+	return strings.HasPrefix(key, "_") && !isLogical(key) && !isMeta(key)
+}
+
+func parseWhere(value any, path string) (WhereNode, error) {
+	obj, ok := value.(object)
+	if !ok {
+		return nil, errorf(path, ErrInvalidNode, "expected object")
+	}
+
+	var (
+		nodes []WhereNode
+		meta  map[string]any
+	)
+	for _, m := range obj {
+		p := path + "." + m.key
+		switch {
+		case isMeta(m.key):
+			if meta == nil {
+				meta = make(map[string]any)
+			}
+			meta[m.key] = plain(m.value)
+		case m.key == string(And) || m.key == string(Or):
+			items, ok := m.value.([]any)
+			if !ok {
+				return nil, errorf(p, ErrInvalidNode, "expected array")
+			}
+			group := &LogicalGroup{Op: LogicalOp(m.key), Children: make([]WhereNode, 0, len(items))}
+			for i, item := range items {
+				child, err := parseWhere(item, fmt.Sprintf("%s[%d]", p, i))
+				if err != nil {
+					return nil, err
+				}
+				group.Children = append(group.Children, child)
+			}
+			nodes = append(nodes, group)
+		case m.key == string(Not):
+			child, err := parseWhere(m.value, p)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, &LogicalGroup{Op: Not, Children: []WhereNode{child}})
+		case isOperator(m.key):
+			return nil, &Error{Path: p, Err: ErrOperatorWithoutField}
+		default:
+			fieldNodes, err := parseField(m.key, m.value, p)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, fieldNodes...)
+		}
+	}
+
+	if len(nodes) == 1 && meta == nil {
+		return nodes[0], nil
+	}
+	return &LogicalGroup{Op: And, Children: nodes, Implicit: true, Meta: meta}, nil
+}
+
+// parseField parses the value of a field member, which is either a set of
+// operators applied to a column or a where tree applied to a relation.
+func parseField(field string, value any, path string) ([]WhereNode, error) {
+	obj, ok := value.(object)
+	if !ok {
+		return nil, errorf(path, ErrInvalidNode, "expected object")
+	}
+	if len(obj) == 0 {
+		return []WhereNode{&Relation{Field: field}}, nil
+	}
+
+	operators, fields := 0, 0
+	for _, m := range obj {
+		switch {
+		case isMeta(m.key):
+		case isOperator(m.key):
+			operators++
+		default:
+			fields++
+		}
+	}
+
+	switch {
+	case operators > 0 && fields == 0:
+		var (
+			nodes = make([]WhereNode, 0, operators)
+			meta  map[string]any
+		)
+		for _, m := range obj {
+			if isMeta(m.key) {
+				if meta == nil {
+					meta = make(map[string]any)
+				}
+				meta[m.key] = plain(m.value)
+				continue
+			}
+			nodes = append(nodes, &Comparison{Field: field, Operator: m.key, Value: plain(m.value)})
+		}
+		nodes[0].(*Comparison).Meta = meta
+		return nodes, nil
+	case operators == 0:
+		where, err := parseWhere(obj, path)
+		if err != nil {
+			return nil, err
+		}
+		return []WhereNode{&Relation{Field: field, Where: where}}, nil
+	default:
+		return nil, errorf(path, ErrInvalidNode, "mixes operators and fields")
+	}
+}
+
+func parseOrderBy(value any, path string) ([]OrderBy, error) {
+	switch v := value.(type) {
+	case object:
+		return parseOrderByObject(v, nil, path)
+	case []any:
+		var entries []OrderBy
+		for i, item := range v {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			obj, ok := item.(object)
+			if !ok {
+				return nil, errorf(p, ErrInvalidNode, "expected object")
+			}
+			items, err := parseOrderByObject(obj, nil, p)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, items...)
+		}
+		return entries, nil
+	default:
+		return nil, errorf(path, ErrInvalidNode, "expected object or array")
+	}
+}
+
+func parseOrderByObject(obj object, prefix []string, path string) ([]OrderBy, error) {
+	var entries []OrderBy
+	for _, m := range obj {
+		p := path + "." + m.key
+		fieldPath := append(prefix[:len(prefix):len(prefix)], m.key)
+		switch v := m.value.(type) {
+		case string:
+			if !directions[v] {
+				return nil, errorf(p, ErrInvalidDirection, "%q", v)
+			}
+			entries = append(entries, OrderBy{Path: fieldPath, Direction: v})
+		case object:
+			items, err := parseOrderByObject(v, fieldPath, p)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, items...)
+		default:
+			return nil, errorf(p, ErrInvalidDirection, "expected direction or object")
+		}
+	}
+	return entries, nil
+}
+
+func parseCount(value any, path string) (*int64, error) {
+	num, ok := value.(json.Number)
+	if !ok {
+		return nil, errorf(path, ErrInvalidCount, "expected integer")
+	}
+	n, err := num.Int64()
+	if err != nil || n < 0 {
+		return nil, errorf(path, ErrInvalidCount, "expected non-negative integer, got %s", num)
+	}
+	return &n, nil
+}
+
+func parseAggregates(value any, path string) ([]Aggregate, error) {
+	obj, ok := value.(object)
+	if !ok {
+		return nil, errorf(path, ErrInvalidNode, "expected object")
+	}
+
+	aggregates := make([]Aggregate, 0, len(obj))
+	for _, m := range obj {
+		p := path + "." + m.key
+		agg := Aggregate{Function: m.key}
+		switch v := m.value.(type) {
+		case string:
+			agg.Fields = []string{v}
+		case []any:
+			fields, err := stringList(v, p)
+			if err != nil {
+				return nil, err
+			}
+			agg.Fields = fields
+		case object:
+			for _, opt := range v {
+				switch opt.key {
+				case "field":
+					field, ok := opt.value.(string)
+					if !ok {
+						return nil, errorf(p+".field", ErrInvalidNode, "expected string")
+					}
+					agg.Fields = append(agg.Fields, field)
+				case "fields":
+					list, ok := opt.value.([]any)
+					if !ok {
+						return nil, errorf(p+".fields", ErrInvalidNode, "expected array")
+					}
+					fields, err := stringList(list, p+".fields")
+					if err != nil {
+						return nil, err
+					}
+					agg.Fields = append(agg.Fields, fields...)
+				default:
+					if agg.Options == nil {
+						agg.Options = make(map[string]any)
+					}
+					agg.Options[opt.key] = plain(opt.value)
+				}
+			}
+		default:
+			return nil, errorf(p, ErrInvalidNode, "expected field, list of fields or object")
+		}
+		aggregates = append(aggregates, agg)
+	}
+	return aggregates, nil
+}
+
+func stringList(items []any, path string) ([]string, error) {
+	out := make([]string, len(items))
+	for i, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, errorf(fmt.Sprintf("%s[%d]", path, i), ErrInvalidNode, "expected string")
+		}
+		out[i] = s
+	}
+	return out, nil
+}
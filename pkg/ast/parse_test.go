@@ -0,0 +1,240 @@
+package ast
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func int64p(n int64) *int64 { return &n }
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		want   *Filter
+	}{
+		{name: "blank", filter: ``, want: &Filter{}},
+		{name: "null", filter: `null`, want: &Filter{}},
+		{name: "empty where", filter: `{"where":{}}`, want: &Filter{}},
+		{
+			name:   "single comparison",
+			filter: `{"where":{"age":{"_gt":18}}}`,
+			want:   &Filter{Where: &Comparison{Field: "age", Operator: "_gt", Value: json.Number("18")}},
+		},
+		{
+			name:   "several operators on a column",
+			filter: `{"where":{"age":{"_gt":1,"_lt":5}}}`,
+			want: &Filter{Where: &LogicalGroup{Op: And, Implicit: true, Children: []WhereNode{
+				&Comparison{Field: "age", Operator: "_gt", Value: json.Number("1")},
+				&Comparison{Field: "age", Operator: "_lt", Value: json.Number("5")},
+			}}},
+		},
+		{
+			name:   "logical groups",
+			filter: `{"where":{"_or":[{"a":{"_eq":"x"}},{"_not":{"b":{"_in":[1,2]}}}],"_and":[]}}`,
+			want: &Filter{Where: &LogicalGroup{Op: And, Implicit: true, Children: []WhereNode{
+				&LogicalGroup{Op: Or, Children: []WhereNode{
+					&Comparison{Field: "a", Operator: "_eq", Value: "x"},
+					&LogicalGroup{Op: Not, Children: []WhereNode{
+						&Comparison{Field: "b", Operator: "_in", Value: []any{json.Number("1"), json.Number("2")}},
+					}},
+				}},
+				&LogicalGroup{Op: And, Children: []WhereNode{}},
+			}}},
+		},
+		{
+			name:   "relations",
+			filter: `{"where":{"user":{"profile":{"name":{"_eq":"x"}}},"posts":{}}}`,
+			want: &Filter{Where: &LogicalGroup{Op: And, Implicit: true, Children: []WhereNode{
+				&Relation{Field: "user", Where: &Relation{Field: "profile", Where: &Comparison{Field: "name", Operator: "_eq", Value: "x"}}},
+				&Relation{Field: "posts"},
+			}}},
+		},
+		{
+			name:   "object operand",
+			filter: `{"where":{"data":{"_contains":{"k":[1]}}}}`,
+			want:   &Filter{Where: &Comparison{Field: "data", Operator: "_contains", Value: map[string]any{"k": []any{json.Number("1")}}}},
+		},
+		{
+			name:   "order_by object and list forms",
+			filter: `{"order_by":[{"user":{"name":"desc_nulls_last"}},{"id":"asc","age":"desc"}]}`,
+			want: &Filter{OrderBy: []OrderBy{
+				{Path: []string{"user", "name"}, Direction: "desc_nulls_last"},
+				{Path: []string{"id"}, Direction: "asc"},
+				{Path: []string{"age"}, Direction: "desc"},
+			}},
+		},
+		{
+			name:   "limit and offset",
+			filter: `{"limit":10,"offset":0}`,
+			want:   &Filter{Limit: int64p(10), Offset: int64p(0)},
+		},
+		{
+			name:   "aggregates",
+			filter: `{"aggregate":{"count":"*","corr":["y","x"],"percentile_cont":{"field":"p","percentile":0.5},"sum":{"fields":["a","b"]}}}`,
+			want: &Filter{Aggregates: []Aggregate{
+				{Function: "count", Fields: []string{"*"}},
+				{Function: "corr", Fields: []string{"y", "x"}},
+				{Function: "percentile_cont", Fields: []string{"p"}, Options: map[string]any{"percentile": json.Number("0.5")}},
+				{Function: "sum", Fields: []string{"a", "b"}},
+			}},
+		},
+		{
+			name:   "unknown sections and null sections",
+			filter: `{"search":{"q":"x"},"limit":null}`,
+			want:   &Filter{Extra: map[string]json.RawMessage{"search": json.RawMessage(`{"q":"x"}`)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.filter)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(tt.want)
+				t.Errorf("Parse() = %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		want    string
+		wantErr error
+	}{
+		{name: "invalid json", filter: `{"where":`, want: "unexpected EOF"},
+		{name: "trailing data", filter: `{} {}`, want: "unexpected data"},
+		{name: "not an object", filter: `[1]`, want: "filter must be an object", wantErr: ErrInvalidNode},
+		{name: "duplicate keys", filter: `{"where":{"a":{"_eq":1},"a":{"_eq":2}}}`, want: `where: duplicate key: "a"`, wantErr: ErrDuplicateKey},
+		{name: "duplicate operators", filter: `{"where":{"a":{"_eq":1,"_eq":2}}}`, want: `where.a: duplicate key: "_eq"`, wantErr: ErrDuplicateKey},
+		{name: "operator without field", filter: `{"where":{"_gt":1}}`, want: "where._gt: operator used without a field", wantErr: ErrOperatorWithoutField},
+		{name: "nested operator without field", filter: `{"where":{"_or":[{"_eq":1}]}}`, want: "where._or[0]._eq", wantErr: ErrOperatorWithoutField},
+		{name: "field not an object", filter: `{"where":{"a":1}}`, want: "where.a: invalid node: expected object", wantErr: ErrInvalidNode},
+		{name: "mixed operators and fields", filter: `{"where":{"a":{"_eq":1,"b":{}}}}`, want: "where.a: invalid node: mixes operators and fields", wantErr: ErrInvalidNode},
+		{name: "_and not an array", filter: `{"where":{"_and":{}}}`, want: "where._and: invalid node: expected array", wantErr: ErrInvalidNode},
+		{name: "invalid direction", filter: `{"order_by":{"a":"up"}}`, want: `order_by.a: invalid direction: "up"`, wantErr: ErrInvalidDirection},
+		{name: "negative limit", filter: `{"limit":-1}`, want: "limit: invalid count: expected non-negative integer", wantErr: ErrInvalidCount},
+		{name: "fractional offset", filter: `{"offset":1.5}`, want: "offset: invalid count: expected non-negative integer", wantErr: ErrInvalidCount},
+		{name: "invalid aggregate", filter: `{"aggregate":{"count":1}}`, want: "aggregate.count", wantErr: ErrInvalidNode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.filter)
+			if err == nil {
+				t.Fatal("Parse() error = nil, want error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse() error = %q, want it to contain %q", err, tt.want)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Parse() error = %v, want errors.Is %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWalk(t *testing.T) {
+	f, err := Parse(`{"where":{"a":{"_eq":1},"user":{"_or":[{"name":{"_eq":"x"}},{"posts":{"title":{"_eq":"y"}}}]}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var visited []string
+	Walk(f.Where, func(node WhereNode, path []string) bool {
+		if c, ok := node.(*Comparison); ok {
+			visited = append(visited, strings.Join(append(path, c.Field), "."))
+		}
+		return true
+	})
+	want := []string{"a", "user.name", "user.posts.title"}
+	if !reflect.DeepEqual(visited, want) {
+		t.Errorf("Walk() visited %v, want %v", visited, want)
+	}
+
+	visited = nil
+	Walk(f.Where, func(node WhereNode, path []string) bool {
+		if c, ok := node.(*Comparison); ok {
+			visited = append(visited, c.Field)
+		}
+		_, isRelation := node.(*Relation)
+		return !isRelation
+	})
+	if want := []string{"a"}; !reflect.DeepEqual(visited, want) {
+		t.Errorf("Walk() with pruning visited %v, want %v", visited, want)
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{name: "nil", data: nil, want: `{}`},
+		{name: "blank", data: []byte(" \n"), want: `{}`},
+		{name: "document", data: []byte(`{"where":{"a":{"_eq":1}},"limit":5}`), want: `{"where":{"a":{"_eq":1}},"limit":5}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ParseBytes(tt.data)
+			if err != nil {
+				t.Fatalf("ParseBytes() error = %v", err)
+			}
+			if got, _ := f.JSON(); got != tt.want {
+				t.Errorf("ParseBytes() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseBytes([]byte(`{"where":{"_not":{"a":{"_eq":1}}}}`), MaxDepth(3)); !errors.Is(err, ErrMaxDepth) {
+		t.Errorf("ParseBytes() with MaxDepth error = %v, want ErrMaxDepth", err)
+	}
+}
+
+func TestParseWhere(t *testing.T) {
+	tests := []struct {
+		name  string
+		where string
+		want  WhereNode
+	}{
+		{name: "blank", where: ``},
+		{name: "null", where: `null`},
+		{name: "empty", where: `{}`},
+		{
+			name:  "comparison",
+			where: `{"age":{"_gt":18}}`,
+			want:  &Comparison{Field: "age", Operator: "_gt", Value: json.Number("18")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseWhere(tt.where)
+			if err != nil {
+				t.Fatalf("ParseWhere() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseWhere() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	for _, where := range []string{`{"a":1}`, `[]`, `{} {}`, `{"a":{"_eq":1},"a":{"_eq":2}}`} {
+		if _, err := ParseWhere(where); err == nil {
+			t.Errorf("ParseWhere(%s) error = nil, want error", where)
+		} else if !strings.Contains(err.Error(), "ast: where") {
+			t.Errorf("ParseWhere(%s) error = %q, want it to name the where path", where, err)
+		}
+	}
+}
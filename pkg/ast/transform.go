@@ -0,0 +1,57 @@
+package ast
+
+// TransformFunc is called by Transform for each node after its children have
+// been transformed. path is the relation path leading to node. Returning a
+// nil node removes it from the tree.
+type TransformFunc func(node WhereNode, path []string) (WhereNode, error)
+
+// Transform returns a copy of the tree rooted at node with every node
+// replaced by the result of fn, applied bottom-up. node is not modified.
+//
+// A group or relation whose children were all removed is removed as well,
+// so dropping the only condition under a _not or a relation does not leave
+// an empty, and differently meaning, node behind. Groups that were empty to
+// begin with are kept.
+func Transform(node WhereNode, fn TransformFunc) (WhereNode, error) {
+	return transform(node, nil, fn)
+}
+
+func transform(node WhereNode, path []string, fn TransformFunc) (WhereNode, error) {
+	switch n := node.(type) {
+	case nil:
+		return nil, nil
+	case *Comparison:
+		c := *n
+		return fn(&c, path)
+	case *Relation:
+		r := &Relation{Field: n.Field}
+		if n.Where != nil {
+			where, err := transform(n.Where, append(path[:len(path):len(path)], n.Field), fn)
+			if err != nil {
+				return nil, err
+			}
+			if where == nil {
+				return nil, nil
+			}
+			r.Where = where
+		}
+		return fn(r, path)
+	case *LogicalGroup:
+		g := &LogicalGroup{Op: n.Op, Implicit: n.Implicit, Meta: n.Meta, Children: make([]WhereNode, 0, len(n.Children))}
+		for _, child := range n.Children {
+			out, err := transform(child, path, fn)
+			if err != nil {
+				return nil, err
+			}
+			if out != nil {
+				g.Children = append(g.Children, out)
+			}
+		}
+		if len(n.Children) > 0 && len(g.Children) == 0 {
+			return nil, nil
+		}
+		return fn(g, path)
+	default:
+		return fn(node, path)
+	}
+}
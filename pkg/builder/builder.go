@@ -0,0 +1,353 @@
+// Package builder constructs Hasura-style filter JSON from Go code.
+//
+//	q := builder.F("age").Gt(18).
+//		And(builder.F("name").ILike("%john%")).
+//		OrderBy("created_at", builder.Desc).
+//		Limit(10)
+//	filter, err := q.JSON()
+//
+// Field paths use dots to address relations, so F("user.profile.name")
+// produces {"user":{"profile":{"name":{...}}}}.
+package builder
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Direction is an order_by direction.
+type Direction string
+
+const (
+	Asc            Direction = "asc"
+	Desc           Direction = "desc"
+	AscNullsFirst  Direction = "asc_nulls_first"
+	AscNullsLast   Direction = "asc_nulls_last"
+	DescNullsFirst Direction = "desc_nulls_first"
+	DescNullsLast  Direction = "desc_nulls_last"
+)
+
+// valid reports whether d is one of the Hasura order_by directions.
+func (d Direction) valid() bool {
+	switch d {
+	case Asc, Desc, AscNullsFirst, AscNullsLast, DescNullsFirst, DescNullsLast:
+		return true
+	}
+	return false
+}
+
+// Column references a field, optionally through relations, and creates
+// comparison expressions on it.
+type Column struct {
+	path string
+}
+
+// F returns a Column for the given dotted field path.
+func F(path string) Column {
+	return Column{path: path}
+}
+
+func (c Column) Eq(value any) Expr          { return c.Op("_eq", value) }
+func (c Column) Neq(value any) Expr         { return c.Op("_neq", value) }
+func (c Column) Gt(value any) Expr          { return c.Op("_gt", value) }
+func (c Column) Gte(value any) Expr         { return c.Op("_gte", value) }
+func (c Column) Lt(value any) Expr          { return c.Op("_lt", value) }
+func (c Column) Lte(value any) Expr         { return c.Op("_lte", value) }
+func (c Column) Like(pattern string) Expr   { return c.Op("_like", pattern) }
+func (c Column) NLike(pattern string) Expr  { return c.Op("_nlike", pattern) }
+func (c Column) ILike(pattern string) Expr  { return c.Op("_ilike", pattern) }
+func (c Column) NILike(pattern string) Expr { return c.Op("_nilike", pattern) }
+func (c Column) Regex(pattern string) Expr  { return c.Op("_regex", pattern) }
+func (c Column) IRegex(pattern string) Expr { return c.Op("_iregex", pattern) }
+func (c Column) IsNull(isNull bool) Expr    { return c.Op("_is_null", isNull) }
+
+// In matches any of values. Calling it without values produces an empty list.
+func (c Column) In(values ...any) Expr {
+	return c.Op("_in", list(values))
+}
+
+// Nin matches none of values.
+func (c Column) Nin(values ...any) Expr {
+	return c.Op("_nin", list(values))
+}
+
+// Op creates a comparison with an arbitrary operator, for operators that have
+// no dedicated method (e.g. dialect-specific ones such as "_contains").
+func (c Column) Op(operator string, value any) Expr {
+	if c.path == "" {
+		return Expr{err: &Error{Msg: "empty", Err: ErrInvalidField}}
+	}
+	if !strings.HasPrefix(operator, "_") {
+		return Expr{err: &Error{Path: c.path, Msg: strconv.Quote(operator), Err: ErrInvalidOperator}}
+	}
+
+	segments := strings.Split(c.path, ".")
+	for _, segment := range segments {
+		if segment == "" {
+			return Expr{err: &Error{Path: c.path, Err: ErrInvalidField}}
+		}
+	}
+
+	var node any = map[string]any{operator: value}
+	for i := len(segments) - 1; i >= 0; i-- {
+		node = map[string]any{segments[i]: node}
+	}
+	return Expr{node: node}
+}
+
+func list(values []any) []any {
+	if values == nil {
+		return []any{}
+	}
+	return values
+}
+
+// Expr is a where expression. The zero Expr means "no condition" and is
+// skipped when combined with And or Or, so expressions can be built up
+// conditionally.
+type Expr struct {
+	op       string
+	children []Expr
+	node     any
+	err      error
+}
+
+// And combines exprs into an _and group. Nested _and groups are flattened and
+// zero Exprs are skipped; if none remain the result is the zero Expr.
+func And(exprs ...Expr) Expr {
+	return group("_and", exprs)
+}
+
+// Or combines exprs into an _or group. Nested _or groups are flattened and
+// zero Exprs are skipped; if none remain the result is the zero Expr.
+func Or(exprs ...Expr) Expr {
+	return group("_or", exprs)
+}
+
+// Not negates expr. Negating the zero Expr yields the zero Expr, so
+// optional conditions can be negated before they are known to exist.
+func Not(expr Expr) Expr {
+	if expr.err != nil || expr.isZero() {
+		return expr
+	}
+	return Expr{op: "_not", children: []Expr{expr}}
+}
+
+func group(op string, exprs []Expr) Expr {
+	children := make([]Expr, 0, len(exprs))
+	for _, expr := range exprs {
+		switch {
+		case expr.err != nil:
+			return expr
+		case expr.isZero():
+		case expr.op == op:
+			children = append(children, expr.children...)
+		default:
+			children = append(children, expr)
+		}
+	}
+	switch len(children) {
+	case 0:
+		return Expr{}
+	case 1:
+		return children[0]
+	default:
+		return Expr{op: op, children: children}
+	}
+}
+
+func (e Expr) isZero() bool {
+	return e.op == "" && e.node == nil && e.err == nil
+}
+
+// And returns e combined with others in an _and group.
+func (e Expr) And(others ...Expr) Expr {
+	return And(append([]Expr{e}, others...)...)
+}
+
+// Or returns e combined with others in an _or group.
+func (e Expr) Or(others ...Expr) Expr {
+	return Or(append([]Expr{e}, others...)...)
+}
+
+// Not returns the negation of e.
+func (e Expr) Not() Expr {
+	return Not(e)
+}
+
+// OrderBy starts a Query filtered by e.
+func (e Expr) OrderBy(field string, dir Direction) *Query {
+	return Where(e).OrderBy(field, dir)
+}
+
+// Limit starts a Query filtered by e.
+func (e Expr) Limit(n int) *Query {
+	return Where(e).Limit(n)
+}
+
+// Offset starts a Query filtered by e.
+func (e Expr) Offset(n int) *Query {
+	return Where(e).Offset(n)
+}
+
+// JSON returns the filter document {"where": e}.
+func (e Expr) JSON() (string, error) {
+	return Where(e).JSON()
+}
+
+// Err returns the first error recorded while building e.
+func (e Expr) Err() error {
+	return e.err
+}
+
+// MarshalJSON encodes e as a where object.
+func (e Expr) MarshalJSON() ([]byte, error) {
+	node, err := e.build()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(node)
+}
+
+func (e Expr) build() (any, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	switch e.op {
+	case "":
+		if e.node == nil {
+			return map[string]any{}, nil
+		}
+		return e.node, nil
+	case "_not":
+		child, err := e.children[0].build()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"_not": child}, nil
+	default:
+		children := make([]any, 0, len(e.children))
+		for _, expr := range e.children {
+			child, err := expr.build()
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, child)
+		}
+		return map[string]any{e.op: children}, nil
+	}
+}
+
+// Query is a complete filter document with where, order_by, limit and
+// offset sections.
+type Query struct {
+	where   Expr
+	orderBy []any
+	limit   *int
+	offset  *int
+	err     error
+}
+
+// Where starts a Query filtered by expr.
+func Where(expr Expr) *Query {
+	return &Query{where: expr}
+}
+
+// Where adds expr to the query, combining it with any existing where
+// expression using _and.
+func (q *Query) Where(expr Expr) *Query {
+	q.where = And(q.where, expr)
+	return q
+}
+
+// OrderBy appends an order_by entry. Relations are addressed with dots.
+func (q *Query) OrderBy(field string, dir Direction) *Query {
+	if !dir.valid() {
+		q.setErr(&Error{Path: q.orderByPath(), Msg: strconv.Quote(string(dir)), Err: ErrInvalidDirection})
+		return q
+	}
+	if field == "" {
+		q.setErr(&Error{Path: q.orderByPath(), Msg: "empty", Err: ErrInvalidField})
+		return q
+	}
+
+	segments := strings.Split(field, ".")
+	var entry any = string(dir)
+	for i := len(segments) - 1; i >= 0; i-- {
+		if segments[i] == "" {
+			q.setErr(&Error{Path: q.orderByPath(), Msg: strconv.Quote(field), Err: ErrInvalidField})
+			return q
+		}
+		entry = map[string]any{segments[i]: entry}
+	}
+	q.orderBy = append(q.orderBy, entry)
+	return q
+}
+
+// Limit sets the limit section.
+func (q *Query) Limit(n int) *Query {
+	if n < 0 {
+		q.setErr(&Error{Path: "limit", Msg: strconv.Itoa(n), Err: ErrInvalidCount})
+		return q
+	}
+	q.limit = &n
+	return q
+}
+
+// Offset sets the offset section.
+func (q *Query) Offset(n int) *Query {
+	if n < 0 {
+		q.setErr(&Error{Path: "offset", Msg: strconv.Itoa(n), Err: ErrInvalidCount})
+		return q
+	}
+	q.offset = &n
+	return q
+}
+
+// orderByPath returns the path of the next order_by entry.
+func (q *Query) orderByPath() string {
+	return fmt.Sprintf("order_by[%d]", len(q.orderBy))
+}
+
+func (q *Query) setErr(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+// JSON returns the filter document as a string.
+func (q *Query) JSON() (string, error) {
+	b, err := q.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// MarshalJSON encodes the filter document.
+func (q *Query) MarshalJSON() ([]byte, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+
+	doc := make(map[string]any)
+	if !q.where.isZero() {
+		where, err := q.where.build()
+		if err != nil {
+			return nil, err
+		}
+		doc["where"] = where
+	}
+	if len(q.orderBy) > 0 {
+		doc["order_by"] = q.orderBy
+	}
+	if q.limit != nil {
+		doc["limit"] = *q.limit
+	}
+	if q.offset != nil {
+		doc["offset"] = *q.offset
+	}
+	return json.Marshal(doc)
+}
@@ -0,0 +1,113 @@
+package builder
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestQueryJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		query *Query
+		want  string
+	}{
+		{
+			name:  "fluent chain",
+			query: F("age").Gt(18).And(F("name").ILike("%john%")).OrderBy("created_at", Desc).Limit(10),
+			want:  `{"limit":10,"order_by":[{"created_at":"desc"}],"where":{"_and":[{"age":{"_gt":18}},{"name":{"_ilike":"%john%"}}]}}`,
+		},
+		{
+			name:  "relation paths",
+			query: Where(F("user.profile.name").Eq("x")).OrderBy("user.name", Asc),
+			want:  `{"order_by":[{"user":{"name":"asc"}}],"where":{"user":{"profile":{"name":{"_eq":"x"}}}}}`,
+		},
+		{
+			name:  "nested groups are flattened",
+			query: Where(And(F("a").Eq(1), And(F("b").Eq(2), F("c").Eq(3)))),
+			want:  `{"where":{"_and":[{"a":{"_eq":1}},{"b":{"_eq":2}},{"c":{"_eq":3}}]}}`,
+		},
+		{
+			name:  "or and not",
+			query: Where(Or(F("a").Eq(1), Not(F("b").In()))),
+			want:  `{"where":{"_or":[{"a":{"_eq":1}},{"_not":{"b":{"_in":[]}}}]}}`,
+		},
+		{
+			name:  "zero exprs are skipped",
+			query: Where(Expr{}).Where(F("a").IsNull(true)).Where(Expr{}),
+			want:  `{"where":{"a":{"_is_null":true}}}`,
+		},
+		{
+			name:  "only zero exprs",
+			query: Where(Expr{}).Where(And(Expr{}, Expr{})).Where(Or()),
+			want:  `{}`,
+		},
+		{
+			name:  "negated zero exprs are skipped",
+			query: Where(Not(Expr{})).Where(And(F("a").Eq(1), Expr{}.Not())),
+			want:  `{"where":{"a":{"_eq":1}}}`,
+		},
+		{
+			name:  "nulls directions",
+			query: Where(Expr{}).OrderBy("a", AscNullsFirst).OrderBy("b", DescNullsLast),
+			want:  `{"order_by":[{"a":"asc_nulls_first"},{"b":"desc_nulls_last"}]}`,
+		},
+		{
+			name:  "offset",
+			query: F("a").Neq("x").Offset(20),
+			want:  `{"offset":20,"where":{"a":{"_neq":"x"}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.query.JSON()
+			if err != nil {
+				t.Fatalf("JSON() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("JSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryJSONErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    *Query
+		wantPath string
+		wantErr  error
+	}{
+		{name: "empty field", query: Where(F("").Eq(1)), wantErr: ErrInvalidField},
+		{name: "empty path segment", query: Where(F("user..name").Eq(1)), wantPath: "user..name", wantErr: ErrInvalidField},
+		{name: "invalid operator", query: Where(F("a").Op("eq", 1)), wantPath: "a", wantErr: ErrInvalidOperator},
+		{name: "error inside group", query: Where(F("a").Eq(1).Or(F("b.").Eq(2))), wantPath: "b.", wantErr: ErrInvalidField},
+		{name: "invalid direction", query: Where(Expr{}).OrderBy("a", Asc).OrderBy("b", "up"), wantPath: "order_by[1]", wantErr: ErrInvalidDirection},
+		{name: "invalid order_by field", query: Where(Expr{}).OrderBy("user.", Asc), wantPath: "order_by[0]", wantErr: ErrInvalidField},
+		{name: "negative limit", query: Where(Expr{}).Limit(-1), wantPath: "limit", wantErr: ErrInvalidCount},
+		{name: "negative offset", query: Where(Expr{}).Offset(-1), wantPath: "offset", wantErr: ErrInvalidCount},
+		{name: "unmarshalable value", query: Where(F("a").Eq(make(chan int)))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.query.JSON()
+			if err == nil {
+				t.Fatal("JSON() error = nil, want error")
+			}
+			if tt.wantErr == nil {
+				return
+			}
+			var builderErr *Error
+			if !errors.As(err, &builderErr) {
+				t.Fatalf("JSON() error = %v, want *Error", err)
+			}
+			if builderErr.Path != tt.wantPath {
+				t.Errorf("Path = %q, want %q", builderErr.Path, tt.wantPath)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("JSON() error = %v, want errors.Is %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package builder
+
+import "errors"
+
+var (
+	// ErrInvalidField is reported for an empty field path or one with an
+	// empty segment, e.g. "user..name".
+	ErrInvalidField = errors.New("invalid field path")
+	// ErrInvalidOperator is reported for an operator that does not start
+	// with an underscore.
+	ErrInvalidOperator = errors.New("invalid operator")
+	// ErrInvalidDirection is reported for an unknown order_by direction.
+	ErrInvalidDirection = errors.New("invalid order direction")
+	// ErrInvalidCount is reported for a negative limit or offset.
+	ErrInvalidCount = errors.New("invalid count")
+)
+
+// Error is returned by JSON and MarshalJSON for an expression or query
+// built with invalid arguments. Use errors.As to retrieve it.
+type Error struct {
+	// Path locates the invalid argument: the field path of a comparison,
+	// e.g. "user.email", or the section of the document, e.g.
+	// "order_by[1]" or "limit".
+	Path string
+	// Msg describes the problem, adding detail to Err when both are set.
+	Msg string
+	// Err is one of the Err variables of this package.
+	Err error
+}
+
+func (e *Error) Error() string {
+	msg := e.Msg
+	if e.Err != nil {
+		if msg == "" {
+			msg = e.Err.Error()
+		} else {
+			msg = e.Err.Error() + ": " + msg
+		}
+	}
+	if e.Path == "" {
+		return "builder: " + msg
+	}
+	return "builder: " + e.Path + ": " + msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,55 @@
+package builder
+
+import "time"
+
+// Scalar is the set of value types a typed Field can compare against.
+type Scalar interface {
+	~string | ~bool |
+		~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64 |
+		time.Time
+}
+
+// Field is a field reference whose comparison values are checked at compile
+// time:
+//
+//	builder.Field[int]("age").Gt(18)
+//	builder.Field[string]("name").In("a", "b")
+//
+// Operators without a typed counterpart, such as pattern matching, are
+// available through Column.
+type Field[T Scalar] string
+
+// Column returns the untyped Column for f.
+func (f Field[T]) Column() Column {
+	return F(string(f))
+}
+
+func (f Field[T]) Eq(value T) Expr  { return f.Column().Eq(value) }
+func (f Field[T]) Neq(value T) Expr { return f.Column().Neq(value) }
+func (f Field[T]) Gt(value T) Expr  { return f.Column().Gt(value) }
+func (f Field[T]) Gte(value T) Expr { return f.Column().Gte(value) }
+func (f Field[T]) Lt(value T) Expr  { return f.Column().Lt(value) }
+func (f Field[T]) Lte(value T) Expr { return f.Column().Lte(value) }
+func (f Field[T]) IsNull(isNull bool) Expr {
+	return f.Column().IsNull(isNull)
+}
+
+// In matches any of values.
+func (f Field[T]) In(values ...T) Expr {
+	return f.Column().In(anySlice(values)...)
+}
+
+// Nin matches none of values.
+func (f Field[T]) Nin(values ...T) Expr {
+	return f.Column().Nin(anySlice(values)...)
+}
+
+func anySlice[T any](values []T) []any {
+	out := make([]any, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
@@ -0,0 +1,45 @@
+package builder
+
+import (
+	"testing"
+	"time"
+)
+
+func TestField(t *testing.T) {
+	type status string
+
+	tests := []struct {
+		name string
+		expr Expr
+		want string
+	}{
+		{name: "int", expr: Field[int]("age").Gt(18), want: `{"where":{"age":{"_gt":18}}}`},
+		{name: "string in", expr: Field[string]("name").In("a", "b"), want: `{"where":{"name":{"_in":["a","b"]}}}`},
+		{name: "empty nin", expr: Field[int]("id").Nin(), want: `{"where":{"id":{"_nin":[]}}}`},
+		{name: "named type", expr: Field[status]("status").Eq("open"), want: `{"where":{"status":{"_eq":"open"}}}`},
+		{name: "float", expr: Field[float64]("price").Lte(9.5), want: `{"where":{"price":{"_lte":9.5}}}`},
+		{
+			name: "time",
+			expr: Field[time.Time]("created_at").Gte(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+			want: `{"where":{"created_at":{"_gte":"2024-01-02T03:04:05Z"}}}`,
+		},
+		{name: "is null", expr: Field[bool]("deleted").IsNull(true), want: `{"where":{"deleted":{"_is_null":true}}}`},
+		{
+			name: "combined with untyped",
+			expr: Field[int]("age").Lt(30).And(Field[string]("name").Column().Like("j%")),
+			want: `{"where":{"_and":[{"age":{"_lt":30}},{"name":{"_like":"j%"}}]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.expr.JSON()
+			if err != nil {
+				t.Fatalf("JSON() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("JSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
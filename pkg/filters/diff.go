@@ -0,0 +1,418 @@
+package filters
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jmag-ic/gosura/pkg/ast"
+)
+
+// ChangeKind is the kind of a Change.
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "added"
+	Removed ChangeKind = "removed"
+	Changed ChangeKind = "changed"
+)
+
+// Change is a single difference reported by Diff.
+type Change struct {
+	Kind ChangeKind
+	// Section is the top-level section the change belongs to: where,
+	// order_by, limit, offset, aggregate or the name of another section.
+	Section string
+	// Path identifies the changed element within the section. For where
+	// it is the dotted field path prefixed by the position of the
+	// condition in the normalized tree: the index of each enclosing _or
+	// branch and each enclosing _not, e.g. "_or[1].user.email" or
+	// "_not.age". _or branches are numbered as in the new filter, or as in
+	// the old one for branches that were removed. For order_by it is the dotted field path, for aggregate
+	// the function name, and it is empty for scalar sections.
+	Path string
+	// Operator is the comparison operator of a where change. Empty groups
+	// are reported with their logical operator and relations without
+	// conditions with an empty operator.
+	Operator string
+	// Old and New hold the previous and new value; Old is nil for Added
+	// and New is nil for Removed.
+	Old, New any
+}
+
+// Diff reports the differences between filters a and b. Both are
+// normalized first, so differences in key order, grouping of _and
+// conditions or annotations are not reported.
+//
+// _or branches are matched by content first, so branches present in both
+// filters are not reported wherever they appear. The remaining branches
+// of an _or group are paired in normalized order and compared condition by
+// condition; unpaired branches are reported as removed or added
+// conditions.
+//
+// Conditions are matched by their position in the normalized tree, path
+// and operator, so moving a condition to another _or branch or into or out
+// of a _not is reported as a removal and an addition. When the same
+// position, path and operator appear several times, unchanged values are
+// matched first and the rest are paired in document order. A change in the relative order of
+// order_by entries is reported as a single Changed entry with an empty Path
+// holding the old and new order.
+func Diff(a, b string) ([]Change, error) {
+	left, err := parseNormalized(a)
+	if err != nil {
+		return nil, err
+	}
+	right, err := parseNormalized(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	changes = append(changes, diffWhere(left.Where, right.Where)...)
+	changes = append(changes, diffOrderBy(left.OrderBy, right.OrderBy)...)
+	changes = append(changes, diffScalar("limit", left.Limit, right.Limit)...)
+	changes = append(changes, diffScalar("offset", left.Offset, right.Offset)...)
+	changes = append(changes, diffAggregates(left.Aggregates, right.Aggregates)...)
+	changes = append(changes, diffExtra(left.Extra, right.Extra)...)
+	return changes, nil
+}
+
+func parseNormalized(filter string) (*ast.Filter, error) {
+	f, err := ast.Parse(filter)
+	if err != nil {
+		return nil, err
+	}
+	return f.Normalize()
+}
+
+type conditionKey struct {
+	path     string
+	operator string
+}
+
+func diffWhere(a, b ast.WhereNode) []Change {
+	changes := diffNodes(a, b, nil)
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Path != changes[j].Path {
+			return changes[i].Path < changes[j].Path
+		}
+		return changes[i].Operator < changes[j].Operator
+	})
+	return changes
+}
+
+// diffNodes reports the differences between the where trees a and b found
+// at context, the position they both have in the whole tree.
+func diffNodes(a, b ast.WhereNode, context []string) []Change {
+	left, right := collect(a, context), collect(b, context)
+
+	keys := make([]conditionKey, 0, len(left.conditions)+len(right.conditions))
+	for key := range left.conditions {
+		keys = append(keys, key)
+	}
+	for key := range right.conditions {
+		if _, ok := left.conditions[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	var changes []Change
+	for _, key := range keys {
+		removed, added := unmatched(left.conditions[key], right.conditions[key])
+		for i := 0; i < len(removed) || i < len(added); i++ {
+			change := Change{Section: "where", Path: key.path, Operator: key.operator}
+			switch {
+			case i >= len(added):
+				change.Kind, change.Old = Removed, removed[i]
+			case i >= len(removed):
+				change.Kind, change.New = Added, added[i]
+			default:
+				change.Kind, change.Old, change.New = Changed, removed[i], added[i]
+			}
+			changes = append(changes, change)
+		}
+	}
+
+	positions := make([]string, 0, len(left.ors)+len(right.ors))
+	for position := range left.ors {
+		positions = append(positions, position)
+	}
+	for position := range right.ors {
+		if _, ok := left.ors[position]; !ok {
+			positions = append(positions, position)
+		}
+	}
+	sort.Strings(positions)
+
+	for _, position := range positions {
+		l, r := left.ors[position], right.ors[position]
+		groupContext := l.context
+		if groupContext == nil {
+			groupContext = r.context
+		}
+		removed, added := unmatchedIndexes(l.groups, r.groups)
+		for i := 0; i < len(removed) || i < len(added); i++ {
+			var old, updated []ast.WhereNode
+			if i < len(removed) {
+				old = l.groups[removed[i]].(*ast.LogicalGroup).Children
+			}
+			if i < len(added) {
+				updated = r.groups[added[i]].(*ast.LogicalGroup).Children
+			}
+			changes = append(changes, diffBranches(old, updated, groupContext)...)
+		}
+	}
+	return changes
+}
+
+// diffBranches reports the differences between the branches of two _or
+// groups at context. Branches present in both are skipped, and the rest
+// are paired in order and compared condition by condition.
+func diffBranches(a, b []ast.WhereNode, context []string) []Change {
+	left := make([]any, len(a))
+	for i, branch := range a {
+		left[i] = branch
+	}
+	right := make([]any, len(b))
+	for i, branch := range b {
+		right[i] = branch
+	}
+
+	var changes []Change
+	removed, added := unmatchedIndexes(left, right)
+	for i := 0; i < len(removed) || i < len(added); i++ {
+		var old, updated ast.WhereNode
+		var index int
+		if i < len(removed) {
+			old, index = a[removed[i]], removed[i]
+		}
+		if i < len(added) {
+			updated, index = b[added[i]], added[i]
+		}
+		branchContext := append(context[:len(context):len(context)], fmt.Sprintf("%s[%d]", ast.Or, index))
+		changes = append(changes, diffNodes(old, updated, branchContext)...)
+	}
+	return changes
+}
+
+// whereParts is a where tree split into its conditions outside _or groups,
+// keyed by position, path and operator, and its non-empty _or groups,
+// keyed by position.
+type whereParts struct {
+	conditions map[conditionKey][]any
+	ors        map[string]orGroups
+}
+
+type orGroups struct {
+	context []string
+	groups  []any
+}
+
+func collect(node ast.WhereNode, context []string) whereParts {
+	parts := whereParts{conditions: make(map[conditionKey][]any), ors: make(map[string]orGroups)}
+	var walk func(node ast.WhereNode, context []string)
+	walk = func(node ast.WhereNode, context []string) {
+		switch n := node.(type) {
+		case *ast.Comparison:
+			key := conditionKey{path: joinPath(context, n.Field), operator: n.Operator}
+			parts.conditions[key] = append(parts.conditions[key], n.Value)
+		case *ast.Relation:
+			inner := append(context[:len(context):len(context)], n.Field)
+			if n.Where == nil {
+				key := conditionKey{path: strings.Join(inner, ".")}
+				parts.conditions[key] = append(parts.conditions[key], nil)
+				return
+			}
+			walk(n.Where, inner)
+		case *ast.LogicalGroup:
+			switch {
+			case len(n.Children) == 0:
+				key := conditionKey{path: strings.Join(context, "."), operator: string(n.Op)}
+				parts.conditions[key] = append(parts.conditions[key], []any{})
+			case n.Op == ast.Or:
+				position := strings.Join(context, ".")
+				ors := parts.ors[position]
+				ors.context = context
+				ors.groups = append(ors.groups, n)
+				parts.ors[position] = ors
+			case n.Op == ast.Not:
+				for _, child := range n.Children {
+					walk(child, append(context[:len(context):len(context)], string(n.Op)))
+				}
+			default:
+				for _, child := range n.Children {
+					walk(child, context)
+				}
+			}
+		}
+	}
+	if node != nil {
+		walk(node, context)
+	}
+	return parts
+}
+
+// unmatched returns the values of a and b left after removing the values
+// they have in common.
+func unmatched(a, b []any) (removed, added []any) {
+	removedIndexes, addedIndexes := unmatchedIndexes(a, b)
+	for _, i := range removedIndexes {
+		removed = append(removed, a[i])
+	}
+	for _, i := range addedIndexes {
+		added = append(added, b[i])
+	}
+	return removed, added
+}
+
+// unmatchedIndexes returns the indexes of the values of a and b left after
+// removing the values they have in common.
+func unmatchedIndexes(a, b []any) (removed, added []int) {
+	used := make([]bool, len(b))
+outer:
+	for i, value := range a {
+		for j, other := range b {
+			if !used[j] && sameJSON(value, other) {
+				used[j] = true
+				continue outer
+			}
+		}
+		removed = append(removed, i)
+	}
+	for j := range b {
+		if !used[j] {
+			added = append(added, j)
+		}
+	}
+	return removed, added
+}
+
+func diffOrderBy(a, b []ast.OrderBy) []Change {
+	index := func(entries []ast.OrderBy) map[string]string {
+		m := make(map[string]string, len(entries))
+		for _, entry := range entries {
+			m[strings.Join(entry.Path, ".")] = entry.Direction
+		}
+		return m
+	}
+	left, right := index(a), index(b)
+
+	var changes []Change
+	var leftOrder, rightOrder []string
+	for _, entry := range a {
+		path := strings.Join(entry.Path, ".")
+		direction, ok := right[path]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Kind: Removed, Section: "order_by", Path: path, Old: entry.Direction})
+		case direction != entry.Direction:
+			changes = append(changes, Change{Kind: Changed, Section: "order_by", Path: path, Old: entry.Direction, New: direction})
+			leftOrder = append(leftOrder, path)
+		default:
+			leftOrder = append(leftOrder, path)
+		}
+	}
+	for _, entry := range b {
+		path := strings.Join(entry.Path, ".")
+		if _, ok := left[path]; !ok {
+			changes = append(changes, Change{Kind: Added, Section: "order_by", Path: path, New: entry.Direction})
+			continue
+		}
+		rightOrder = append(rightOrder, path)
+	}
+
+	if !slices.Equal(leftOrder, rightOrder) {
+		changes = append(changes, Change{Kind: Changed, Section: "order_by", Old: leftOrder, New: rightOrder})
+	}
+	return changes
+}
+
+func diffScalar(section string, a, b *int64) []Change {
+	switch {
+	case a == nil && b == nil:
+		return nil
+	case a == nil:
+		return []Change{{Kind: Added, Section: section, New: *b}}
+	case b == nil:
+		return []Change{{Kind: Removed, Section: section, Old: *a}}
+	case *a != *b:
+		return []Change{{Kind: Changed, Section: section, Old: *a, New: *b}}
+	default:
+		return nil
+	}
+}
+
+func diffAggregates(a, b []ast.Aggregate) []Change {
+	right := make(map[string]ast.Aggregate, len(b))
+	for _, agg := range b {
+		right[agg.Function] = agg
+	}
+	left := make(map[string]bool, len(a))
+
+	var changes []Change
+	for _, agg := range a {
+		left[agg.Function] = true
+		other, ok := right[agg.Function]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Kind: Removed, Section: "aggregate", Path: agg.Function, Old: agg})
+		case !sameJSON(agg, other):
+			changes = append(changes, Change{Kind: Changed, Section: "aggregate", Path: agg.Function, Old: agg, New: other})
+		}
+	}
+	for _, agg := range b {
+		if !left[agg.Function] {
+			changes = append(changes, Change{Kind: Added, Section: "aggregate", Path: agg.Function, New: agg})
+		}
+	}
+	return changes
+}
+
+func diffExtra(a, b map[string]json.RawMessage) []Change {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []Change
+	for _, key := range keys {
+		old, hadOld := a[key]
+		updated, hasNew := b[key]
+		switch {
+		case !hasNew:
+			changes = append(changes, Change{Kind: Removed, Section: key, Old: old})
+		case !hadOld:
+			changes = append(changes, Change{Kind: Added, Section: key, New: updated})
+		case string(old) != string(updated):
+			changes = append(changes, Change{Kind: Changed, Section: key, Old: old, New: updated})
+		}
+	}
+	return changes
+}
+
+func joinPath(context []string, field string) string {
+	if len(context) == 0 {
+		return field
+	}
+	return strings.Join(context, ".") + "." + field
+}
+
+func sameJSON(a, b any) bool {
+	left, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	right, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(left) == string(right)
+}
@@ -0,0 +1,150 @@
+package filters
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/jmag-ic/gosura/pkg/ast"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Change
+	}{
+		{
+			name: "equivalent filters",
+			a:    `{"where":{"b":{"_eq":2},"a":{"_eq":1},"_comment":"x"},"aggregate":{"sum":"x","count":"*"}}`,
+			b:    `{"where":{"_and":[{"a":{"_eq":1}},{"_and":[{"b":{"_eq":2}}]}]},"aggregate":{"count":"*","sum":"x"}}`,
+		},
+		{
+			name: "changed, added and removed conditions",
+			a:    `{"where":{"a":{"_eq":1},"b":{"_eq":2}}}`,
+			b:    `{"where":{"a":{"_eq":3},"user":{"email":{"_like":"%x"}}}}`,
+			want: []Change{
+				{Kind: Changed, Section: "where", Path: "a", Operator: "_eq", Old: json.Number("1"), New: json.Number("3")},
+				{Kind: Removed, Section: "where", Path: "b", Operator: "_eq", Old: json.Number("2")},
+				{Kind: Added, Section: "where", Path: "user.email", Operator: "_like", New: "%x"},
+			},
+		},
+		{
+			name: "condition moved between _or branches",
+			a:    `{"where":{"_or":[{"a":{"_eq":1},"b":{"_eq":2}},{"c":{"_eq":3}}]}}`,
+			b:    `{"where":{"_or":[{"a":{"_eq":1}},{"b":{"_eq":2},"c":{"_eq":3}}]}}`,
+			want: []Change{
+				{Kind: Removed, Section: "where", Path: "_or[0].b", Operator: "_eq", Old: json.Number("2")},
+				{Kind: Added, Section: "where", Path: "_or[1].b", Operator: "_eq", New: json.Number("2")},
+			},
+		},
+		{
+			name: "changed _or branch",
+			a:    `{"where":{"_or":[{"a":{"_eq":1}},{"a":{"_eq":2},"b":{"_eq":3}}]}}`,
+			b:    `{"where":{"_or":[{"a":{"_eq":5}},{"a":{"_eq":2},"b":{"_eq":3}}]}}`,
+			want: []Change{
+				{Kind: Changed, Section: "where", Path: "_or[1].a", Operator: "_eq", Old: json.Number("1"), New: json.Number("5")},
+			},
+		},
+		{
+			name: "appended _or branch",
+			a:    `{"where":{"_or":[{"name":{"_eq":"bob"}},{"age":{"_gt":30}}]}}`,
+			b:    `{"where":{"_or":[{"name":{"_eq":"bob"}},{"age":{"_gt":30}},{"email":{"_like":"%@x.com"}}]}}`,
+			want: []Change{
+				{Kind: Added, Section: "where", Path: "_or[1].email", Operator: "_like", New: "%@x.com"},
+			},
+		},
+		{
+			name: "nested _or branches",
+			a:    `{"where":{"user":{"_or":[{"a":{"_eq":1}},{"b":{"_eq":2},"_or":[{"c":{"_eq":3}},{"d":{"_eq":4}}]}]}}}`,
+			b:    `{"where":{"user":{"_or":[{"a":{"_eq":1}},{"b":{"_eq":2},"_or":[{"c":{"_eq":5}},{"d":{"_eq":4}}]}]}}}`,
+			want: []Change{
+				{Kind: Changed, Section: "where", Path: "user._or[0]._or[0].c", Operator: "_eq", Old: json.Number("3"), New: json.Number("5")},
+			},
+		},
+		{
+			name: "condition moved into _not",
+			a:    `{"where":{"a":{"_eq":1},"_not":{"b":{"_eq":2}}}}`,
+			b:    `{"where":{"_not":{"a":{"_eq":1},"b":{"_eq":2}}}}`,
+			want: []Change{
+				{Kind: Added, Section: "where", Path: "_not.a", Operator: "_eq", New: json.Number("1")},
+				{Kind: Removed, Section: "where", Path: "a", Operator: "_eq", Old: json.Number("1")},
+			},
+		},
+		{
+			name: "condition moved out of _not",
+			a:    `{"where":{"_not":{"a":{"_eq":1}}}}`,
+			b:    `{"where":{"a":{"_eq":1}}}`,
+			want: []Change{
+				{Kind: Removed, Section: "where", Path: "_not.a", Operator: "_eq", Old: json.Number("1")},
+				{Kind: Added, Section: "where", Path: "a", Operator: "_eq", New: json.Number("1")},
+			},
+		},
+		{
+			name: "repeated conditions",
+			a:    `{"where":{"_and":[{"a":{"_neq":1}},{"a":{"_neq":2}}]}}`,
+			b:    `{"where":{"_and":[{"a":{"_neq":2}},{"a":{"_neq":3}}]}}`,
+			want: []Change{
+				{Kind: Changed, Section: "where", Path: "a", Operator: "_neq", Old: json.Number("1"), New: json.Number("3")},
+			},
+		},
+		{
+			name: "empty groups and relations",
+			a:    `{"where":{"_or":[]}}`,
+			b:    `{"where":{"user":{}}}`,
+			want: []Change{
+				{Kind: Removed, Section: "where", Operator: "_or", Old: []any{}},
+				{Kind: Added, Section: "where", Path: "user", New: nil},
+			},
+		},
+		{
+			name: "order_by",
+			a:    `{"order_by":[{"a":"asc"},{"b":"desc"},{"c":"asc"}]}`,
+			b:    `{"order_by":[{"b":"asc"},{"a":"asc"},{"d":"asc"}]}`,
+			want: []Change{
+				{Kind: Changed, Section: "order_by", Path: "b", Old: "desc", New: "asc"},
+				{Kind: Removed, Section: "order_by", Path: "c", Old: "asc"},
+				{Kind: Added, Section: "order_by", Path: "d", New: "asc"},
+				{Kind: Changed, Section: "order_by", Old: []string{"a", "b"}, New: []string{"b", "a"}},
+			},
+		},
+		{
+			name: "limit, offset, aggregate and other sections",
+			a:    `{"limit":10,"offset":5,"aggregate":{"count":"*","sum":"x"},"search":{"q":"a"},"old":1}`,
+			b:    `{"limit":20,"aggregate":{"count":"*","max":"y","sum":"z"},"search":{"q":"b"}}`,
+			want: []Change{
+				{Kind: Changed, Section: "limit", Old: int64(10), New: int64(20)},
+				{Kind: Removed, Section: "offset", Old: int64(5)},
+				{Kind: Changed, Section: "aggregate", Path: "sum",
+					Old: ast.Aggregate{Function: "sum", Fields: []string{"x"}},
+					New: ast.Aggregate{Function: "sum", Fields: []string{"z"}}},
+				{Kind: Added, Section: "aggregate", Path: "max", New: ast.Aggregate{Function: "max", Fields: []string{"y"}}},
+				{Kind: Removed, Section: "old", Old: json.RawMessage(`1`)},
+				{Kind: Changed, Section: "search", Old: json.RawMessage(`{"q":"a"}`), New: json.RawMessage(`{"q":"b"}`)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Diff(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("Diff() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffErrors(t *testing.T) {
+	for _, tt := range []struct{ a, b string }{
+		{a: `{"where":`, b: `{}`},
+		{a: `{}`, b: `{"where":{"_eq":1}}`},
+	} {
+		if _, err := Diff(tt.a, tt.b); err == nil {
+			t.Errorf("Diff(%s, %s) error = nil, want error", tt.a, tt.b)
+		}
+	}
+}
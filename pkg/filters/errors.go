@@ -0,0 +1,58 @@
+package filters
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnsupportedOp is returned by Merge for an operator other than And and
+// Or.
+var ErrUnsupportedOp = errors.New("unsupported merge operator")
+
+// Error is returned by Merge for a filter document it cannot read. Use
+// errors.As to retrieve it.
+type Error struct {
+	// Filter is the index of the offending document among the filters
+	// passed to Merge.
+	Filter int
+	// Path is the location of the offending value in that document, e.g.
+	// "where._or" or "order_by[1].user". It is empty for errors about the
+	// whole document.
+	Path string
+	// Msg describes the problem, adding detail to Err when both are set.
+	Msg string
+	// Err is the underlying error, if any: a JSON error, or
+	// ast.ErrInvalidNode or ast.ErrInvalidCount for values of the wrong
+	// shape.
+	Err error
+}
+
+func (e *Error) Error() string {
+	msg := e.Msg
+	if e.Err != nil {
+		if msg == "" {
+			msg = e.Err.Error()
+		} else {
+			msg = e.Err.Error() + ": " + msg
+		}
+	}
+	if e.Path == "" {
+		return fmt.Sprintf("filters: filter %d: %s", e.Filter, msg)
+	}
+	return fmt.Sprintf("filters: filter %d: %s: %s", e.Filter, e.Path, msg)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// filterError returns err as an *Error about filter i. Errors that are not
+// *Error values yet are placed at path.
+func filterError(i int, path string, err error) error {
+	var filterErr *Error
+	if !errors.As(err, &filterErr) {
+		filterErr = &Error{Path: path, Err: err}
+	}
+	filterErr.Filter = i
+	return filterErr
+}
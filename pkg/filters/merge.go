@@ -0,0 +1,337 @@
+// Package filters provides helpers that operate directly on Hasura-style
+// filter JSON documents ({"where": ..., "order_by": ..., "limit": ..., ...}).
+package filters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jmag-ic/gosura/pkg/ast"
+)
+
+// Op is the logical operator used to combine the where sections of merged
+// filters.
+type Op string
+
+const (
+	// And requires every merged where section to match.
+	And Op = "_and"
+	// Or requires at least one merged where section to match.
+	Or Op = "_or"
+)
+
+// Merge combines several filter documents into one.
+//
+// Empty strings, null and {} documents contribute no sections. Sections are
+// merged as follows:
+//
+//   - where: combined with op. A filter whose where section is missing,
+//     null or {} matches everything: And ignores it, while with Or it makes
+//     the merged filter unconstrained, so the result has no where section.
+//     Operands that already are groups of the same op are flattened into
+//     the result. An empty {"_or": []} matches nothing, so merging only
+//     such operands with Or yields {"_or": []}.
+//   - order_by: concatenated in argument order. A field path already
+//     ordered by an earlier filter keeps its earlier direction.
+//   - limit: the smallest limit wins.
+//   - offset and any other section: the last non-null value wins.
+func Merge(op Op, filters ...string) (string, error) {
+	if op != And && op != Or {
+		return "", fmt.Errorf("filters: %w %q", ErrUnsupportedOp, op)
+	}
+
+	var (
+		where         []json.RawMessage
+		unconstrained bool
+		matchesNone   bool
+		orderBy       []orderByEntry
+		ordered       = make(map[string]bool)
+		limit         *int64
+		others        = make(map[string]json.RawMessage)
+	)
+
+	for i, filter := range filters {
+		var doc map[string]json.RawMessage
+		if len(bytes.TrimSpace([]byte(filter))) > 0 {
+			if err := json.Unmarshal([]byte(filter), &doc); err != nil {
+				return "", filterError(i, "", err)
+			}
+		}
+
+		constrained := false
+		for key, raw := range doc {
+			if isNull(raw) {
+				continue
+			}
+			switch key {
+			case "where":
+				operands, ok, err := whereOperands(op, raw)
+				if err != nil {
+					return "", filterError(i, "where", err)
+				}
+				constrained = ok
+				if op == Or && ok && len(operands) == 0 {
+					matchesNone = true
+				}
+				where = append(where, operands...)
+			case "order_by":
+				entries, err := orderByEntries(raw, "order_by")
+				if err != nil {
+					return "", filterError(i, "order_by", err)
+				}
+				for _, entry := range entries {
+					key := strings.Join(entry.path, ".")
+					if ordered[key] {
+						continue
+					}
+					ordered[key] = true
+					orderBy = append(orderBy, entry)
+				}
+			case "limit":
+				var n int64
+				if err := json.Unmarshal(raw, &n); err != nil {
+					return "", &Error{Filter: i, Path: "limit", Msg: fmt.Sprintf("expected integer, got %s", raw), Err: ast.ErrInvalidCount}
+				}
+				if limit == nil || n < *limit {
+					limit = &n
+				}
+			default:
+				others[key] = raw
+			}
+		}
+		if op == Or && !constrained {
+			unconstrained = true
+		}
+	}
+	if unconstrained {
+		where = nil
+	}
+
+	var buf bytes.Buffer
+	w := objectWriter{buf: &buf}
+	buf.WriteByte('{')
+
+	switch len(where) {
+	case 0:
+		if matchesNone && !unconstrained {
+			w.key("where")
+			fmt.Fprintf(&buf, `{"%s":[]}`, op)
+		}
+	case 1:
+		w.key("where")
+		buf.Write(where[0])
+	default:
+		w.key("where")
+		fmt.Fprintf(&buf, `{"%s":[`, op)
+		for i, operand := range where {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(operand)
+		}
+		buf.WriteString("]}")
+	}
+
+	if len(orderBy) > 0 {
+		w.key("order_by")
+		buf.WriteByte('[')
+		for i, entry := range orderBy {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			for _, key := range entry.path {
+				buf.WriteByte('{')
+				writeString(&buf, key)
+				buf.WriteByte(':')
+			}
+			buf.Write(entry.direction)
+			buf.WriteString(strings.Repeat("}", len(entry.path)))
+		}
+		buf.WriteByte(']')
+	}
+
+	if limit != nil {
+		w.key("limit")
+		fmt.Fprintf(&buf, "%d", *limit)
+	}
+
+	if offset, ok := others["offset"]; ok {
+		w.key("offset")
+		buf.Write(offset)
+		delete(others, "offset")
+	}
+
+	keys := make([]string, 0, len(others))
+	for key := range others {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		w.key(key)
+		buf.Write(others[key])
+	}
+
+	buf.WriteByte('}')
+	return buf.String(), nil
+}
+
+// whereOperands returns the compacted operands a where section contributes
+// to a merge with op. It reports false for an empty where section, which
+// matches everything.
+func whereOperands(op Op, raw json.RawMessage) ([]json.RawMessage, bool, error) {
+	if !isObject(raw) {
+		return nil, false, &Error{Path: "where", Msg: "expected object", Err: ast.ErrInvalidNode}
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &members); err != nil {
+		return nil, false, err
+	}
+	if len(members) == 0 {
+		return nil, false, nil
+	}
+
+	if group, ok := members[string(op)]; ok && len(members) == 1 {
+		path := "where." + string(op)
+		if trimmed := bytes.TrimSpace(group); len(trimmed) == 0 || trimmed[0] != '[' {
+			return nil, false, &Error{Path: path, Msg: "expected array", Err: ast.ErrInvalidNode}
+		}
+		var operands []json.RawMessage
+		if err := json.Unmarshal(group, &operands); err != nil {
+			return nil, false, &Error{Path: path, Err: err}
+		}
+		for i := range operands {
+			operands[i] = compact(operands[i])
+		}
+		return operands, true, nil
+	}
+
+	return []json.RawMessage{compact(raw)}, true, nil
+}
+
+// orderByEntry is a single order_by entry with its full field path.
+type orderByEntry struct {
+	path      []string
+	direction json.RawMessage
+}
+
+// orderByEntries returns the order_by entries in document order, accepting
+// both the object form {"a": "asc", "b": "desc"} and the list form
+// [{"a": "asc"}, {"b": "desc"}]. Nested relation objects are expanded into
+// one entry per ordered field. path is the location of raw, used in
+// errors.
+func orderByEntries(raw json.RawMessage, path string) ([]orderByEntry, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		var list []json.RawMessage
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return nil, &Error{Path: path, Err: err}
+		}
+		var entries []orderByEntry
+		for i, item := range list {
+			items, err := expandOrderBy(item, nil, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, items...)
+		}
+		return entries, nil
+	}
+	return expandOrderBy(raw, nil, path)
+}
+
+func expandOrderBy(raw json.RawMessage, prefix []string, path string) ([]orderByEntry, error) {
+	if !isObject(raw) {
+		return nil, &Error{Path: path, Msg: fmt.Sprintf("expected object, got %s", raw), Err: ast.ErrInvalidNode}
+	}
+	members, err := decodeObject(raw)
+	if err != nil {
+		return nil, &Error{Path: path, Err: err}
+	}
+
+	var entries []orderByEntry
+	for _, m := range members {
+		fieldPath := append(prefix[:len(prefix):len(prefix)], m.key)
+		if len(m.value) > 0 && m.value[0] == '{' {
+			nested, err := expandOrderBy(m.value, fieldPath, path+"."+m.key)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, nested...)
+			continue
+		}
+		entries = append(entries, orderByEntry{path: fieldPath, direction: m.value})
+	}
+	return entries, nil
+}
+
+// member is a single key/value pair of a JSON object.
+type member struct {
+	key   string
+	value json.RawMessage
+}
+
+// decodeObject decodes a JSON object preserving the order of its members.
+func decodeObject(raw json.RawMessage) ([]member, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("%w: expected object, got %s", ast.ErrInvalidNode, raw)
+	}
+
+	var members []member
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		members = append(members, member{key: tok.(string), value: compact(value)})
+	}
+	return members, nil
+}
+
+// objectWriter writes comma-separated object keys into buf.
+type objectWriter struct {
+	buf   *bytes.Buffer
+	count int
+}
+
+func (w *objectWriter) key(key string) {
+	if w.count > 0 {
+		w.buf.WriteByte(',')
+	}
+	w.count++
+	writeString(w.buf, key)
+	w.buf.WriteByte(':')
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	b, _ := json.Marshal(s)
+	buf.Write(b)
+}
+
+func compact(raw json.RawMessage) json.RawMessage {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return raw
+	}
+	return buf.Bytes()
+}
+
+func isObject(raw json.RawMessage) bool {
+	raw = bytes.TrimSpace(raw)
+	return len(raw) > 0 && raw[0] == '{'
+}
+
+func isNull(raw json.RawMessage) bool {
+	return string(bytes.TrimSpace(raw)) == "null"
+}
@@ -0,0 +1,177 @@
+package filters
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jmag-ic/gosura/pkg/ast"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name    string
+		op      Op
+		filters []string
+		want    string
+	}{
+		{
+			name: "no filters",
+			op:   And,
+			want: `{}`,
+		},
+		{
+			name:    "empty, null and {} documents are ignored with and",
+			op:      And,
+			filters: []string{``, `null`, `{}`, `{"where":{"a":{"_eq":1}}}`},
+			want:    `{"where":{"a":{"_eq":1}}}`,
+		},
+		{
+			name:    "and combines where sections",
+			op:      And,
+			filters: []string{`{"where":{"a":{"_eq":1}}}`, `{"where":{"b":{"_eq":2}}}`},
+			want:    `{"where":{"_and":[{"a":{"_eq":1}},{"b":{"_eq":2}}]}}`,
+		},
+		{
+			name:    "and ignores empty where sections",
+			op:      And,
+			filters: []string{`{"where":{}}`, `{"where":null}`, `{"where":{"a":{"_eq":1}}}`},
+			want:    `{"where":{"a":{"_eq":1}}}`,
+		},
+		{
+			name:    "and flattens _and operands",
+			op:      And,
+			filters: []string{`{"where":{"_and":[{"a":{"_eq":1}},{"b":{"_eq":2}}]}}`, `{"where":{"c":{"_eq":3}}}`},
+			want:    `{"where":{"_and":[{"a":{"_eq":1}},{"b":{"_eq":2}},{"c":{"_eq":3}}]}}`,
+		},
+		{
+			name:    "and does not flatten _or operands",
+			op:      And,
+			filters: []string{`{"where":{"_or":[{"a":{"_eq":1}},{"b":{"_eq":2}}]}}`, `{"where":{"c":{"_eq":3}}}`},
+			want:    `{"where":{"_and":[{"_or":[{"a":{"_eq":1}},{"b":{"_eq":2}}]},{"c":{"_eq":3}}]}}`,
+		},
+		{
+			name:    "or combines where sections",
+			op:      Or,
+			filters: []string{`{"where":{"a":{"_eq":1}}}`, `{"where":{"b":{"_eq":2}}}`},
+			want:    `{"where":{"_or":[{"a":{"_eq":1}},{"b":{"_eq":2}}]}}`,
+		},
+		{
+			name:    "or with an empty document is unconstrained",
+			op:      Or,
+			filters: []string{`{}`, `{"where":{"a":{"_eq":1}}}`},
+			want:    `{}`,
+		},
+		{
+			name:    "or with a blank document is unconstrained",
+			op:      Or,
+			filters: []string{`{"where":{"a":{"_eq":1}}}`, ``},
+			want:    `{}`,
+		},
+		{
+			name:    "or with a null where is unconstrained",
+			op:      Or,
+			filters: []string{`{"where":null,"limit":5}`, `{"where":{"a":{"_eq":1}}}`},
+			want:    `{"limit":5}`,
+		},
+		{
+			name:    "or with an empty _or operand stays constrained",
+			op:      Or,
+			filters: []string{`{"where":{"_or":[]}}`, `{"where":{"a":{"_eq":1}}}`},
+			want:    `{"where":{"a":{"_eq":1}}}`,
+		},
+		{
+			name:    "or of empty _or operands matches nothing",
+			op:      Or,
+			filters: []string{`{"where":{"_or":[]}}`},
+			want:    `{"where":{"_or":[]}}`,
+		},
+		{
+			name:    "or of empty _or operands and an empty where is unconstrained",
+			op:      Or,
+			filters: []string{`{"where":{"_or":[]}}`, `{"where":{}}`},
+			want:    `{}`,
+		},
+		{
+			name: "order_by keeps the first direction of a field",
+			op:   And,
+			filters: []string{
+				`{"order_by":{"a":"asc","b":"desc"}}`,
+				`{"order_by":[{"b":"asc"},{"c":"desc"}]}`,
+			},
+			want: `{"order_by":[{"a":"asc"},{"b":"desc"},{"c":"desc"}]}`,
+		},
+		{
+			name: "order_by de-duplicates on the full relation path",
+			op:   And,
+			filters: []string{
+				`{"order_by":[{"user":{"name":"asc"}}]}`,
+				`{"order_by":[{"user":{"age":"desc","name":"desc"}}]}`,
+			},
+			want: `{"order_by":[{"user":{"name":"asc"}},{"user":{"age":"desc"}}]}`,
+		},
+		{
+			name:    "smallest limit wins",
+			op:      And,
+			filters: []string{`{"limit":50}`, `{"limit":10}`, `{"limit":20}`},
+			want:    `{"limit":10}`,
+		},
+		{
+			name:    "last offset and other sections win",
+			op:      And,
+			filters: []string{`{"offset":5,"aggregate":{"count":"*"}}`, `{"offset":10,"aggregate":{"sum":"x"}}`, `{"offset":null}`},
+			want:    `{"offset":10,"aggregate":{"sum":"x"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Merge(tt.op, tt.filters...)
+			if err != nil {
+				t.Fatalf("Merge() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Merge() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		op       Op
+		filters  []string
+		wantPath string
+		wantErr  error
+	}{
+		{name: "invalid json", op: And, filters: []string{`{"where":`}},
+		{name: "where not an object", op: And, filters: []string{`{}`, `{"where":[1]}`}, wantPath: "where", wantErr: ast.ErrInvalidNode},
+		{name: "_or not an array", op: Or, filters: []string{`{"where":{"_or":{}}}`}, wantPath: "where._or", wantErr: ast.ErrInvalidNode},
+		{name: "invalid limit", op: And, filters: []string{`{"limit":"ten"}`}, wantPath: "limit", wantErr: ast.ErrInvalidCount},
+		{name: "invalid order_by", op: And, filters: []string{`{"order_by":"a"}`}, wantPath: "order_by", wantErr: ast.ErrInvalidNode},
+		{name: "invalid order_by entry", op: And, filters: []string{`{"order_by":[{"a":"asc"},"b"]}`}, wantPath: "order_by[1]", wantErr: ast.ErrInvalidNode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Merge(tt.op, tt.filters...)
+			var filterErr *Error
+			if !errors.As(err, &filterErr) {
+				t.Fatalf("Merge() error = %v, want *Error", err)
+			}
+			if filterErr.Filter != len(tt.filters)-1 {
+				t.Errorf("Filter = %d, want %d", filterErr.Filter, len(tt.filters)-1)
+			}
+			if filterErr.Path != tt.wantPath {
+				t.Errorf("Path = %q, want %q", filterErr.Path, tt.wantPath)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Merge() error = %v, want errors.Is %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := Merge("_not", `{}`); !errors.Is(err, ErrUnsupportedOp) {
+		t.Errorf("Merge() error = %v, want ErrUnsupportedOp", err)
+	}
+}
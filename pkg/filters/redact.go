@@ -0,0 +1,184 @@
+package filters
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+
+	"github.com/jmag-ic/gosura/pkg/ast"
+)
+
+// Redacted replaces redacted values.
+const Redacted = "***"
+
+// Redact returns filter with the operands of comparisons on the given field
+// paths replaced by Redacted, keeping fields, operators and structure so the
+// result can be logged safely. List operands keep their length. Relations are
+// addressed with dots, e.g. "user.email". _is_null operands are kept since
+// they carry no data.
+//
+// Without fields every operand is redacted, along with every value in
+// other top-level sections and aggregate options. Limit and offset are
+// always kept. The values of _meta and _comment annotations are always
+// redacted.
+//
+// If filter is not valid JSON, every string, number and literal in it is
+// redacted and only object keys and punctuation are kept.
+func Redact(filter string, fields ...string) string {
+	f, err := ast.Parse(filter)
+	if err != nil {
+		return redactText(filter)
+	}
+
+	redacted := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		redacted[field] = true
+	}
+
+	f.Where, err = ast.Transform(f.Where, func(node ast.WhereNode, path []string) (ast.WhereNode, error) {
+		switch n := node.(type) {
+		case *ast.LogicalGroup:
+			n.Meta = redactMeta(n.Meta)
+		case *ast.Comparison:
+			n.Meta = redactMeta(n.Meta)
+			if n.Operator == "_is_null" {
+				break
+			}
+			if len(fields) > 0 && !redacted[strings.Join(append(path[:len(path):len(path)], n.Field), ".")] {
+				break
+			}
+			if list, ok := n.Value.([]any); ok {
+				masked := make([]any, len(list))
+				for i := range masked {
+					masked[i] = Redacted
+				}
+				n.Value = masked
+			} else {
+				n.Value = Redacted
+			}
+		}
+		return node, nil
+	})
+	if err != nil {
+		return redactText(filter)
+	}
+
+	if len(fields) == 0 {
+		for i, agg := range f.Aggregates {
+			if agg.Options != nil {
+				f.Aggregates[i].Options = redactValue(agg.Options).(map[string]any)
+			}
+		}
+	}
+	for key, raw := range f.Extra {
+		if len(fields) > 0 && key != "_meta" && key != "_comment" {
+			continue
+		}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		var value any
+		if err := dec.Decode(&value); err != nil {
+			return redactText(filter)
+		}
+		if f.Extra[key], err = json.Marshal(redactValue(value)); err != nil {
+			return redactText(filter)
+		}
+	}
+
+	out, err := f.JSON()
+	if err != nil {
+		return redactText(filter)
+	}
+	return out
+}
+
+// Redacting returns a log/slog value that logs filter redacted as by
+// Redact. Redaction only happens if the record is written:
+//
+//	logger.Debug("query", "filter", filters.Redacting(filter, "user.email"))
+func Redacting(filter string, fields ...string) slog.LogValuer {
+	return redactingFilter{filter: filter, fields: fields}
+}
+
+type redactingFilter struct {
+	filter string
+	fields []string
+}
+
+func (r redactingFilter) LogValue() slog.Value {
+	return slog.StringValue(Redact(r.filter, r.fields...))
+}
+
+func redactMeta(meta map[string]any) map[string]any {
+	if meta == nil {
+		return nil
+	}
+	return redactValue(meta).(map[string]any)
+}
+
+// redactValue returns a copy of a decoded JSON value with every scalar
+// replaced by Redacted.
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[key] = redactValue(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = redactValue(item)
+		}
+		return out
+	default:
+		return Redacted
+	}
+}
+
+// redactText redacts a document that cannot be parsed. Quoted strings
+// followed by a colon are kept as keys. Every other string and every run
+// of characters outside strings that is not JSON punctuation or space is
+// replaced by a quoted Redacted.
+func redactText(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		switch c := text[i]; {
+		case strings.IndexByte("{}[]:, \t\r\n", c) >= 0:
+			b.WriteByte(c)
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(text) && text[end] != '"' {
+				if text[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(text))
+			if isKey(text[end:]) {
+				b.WriteString(text[i:end])
+			} else {
+				b.WriteString(`"` + Redacted + `"`)
+			}
+			i = end
+		default:
+			end := i
+			for end < len(text) && strings.IndexByte("{}[]:,\" \t\r\n", text[end]) < 0 {
+				end++
+			}
+			b.WriteString(`"` + Redacted + `"`)
+			i = end
+		}
+	}
+	return b.String()
+}
+
+// isKey reports whether rest, the text following a string, starts with a
+// colon.
+func isKey(rest string) bool {
+	rest = strings.TrimLeft(rest, " \t\r\n")
+	return strings.HasPrefix(rest, ":")
+}
@@ -0,0 +1,88 @@
+package filters
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		fields []string
+		want   string
+	}{
+		{
+			name:   "all operands",
+			filter: `{"where":{"email":{"_eq":"a@b.c"},"age":{"_gt":18},"tags":{"_in":["x","y"]},"deleted_at":{"_is_null":true}},"limit":10}`,
+			want:   `{"where":{"email":{"_eq":"***"},"age":{"_gt":"***"},"tags":{"_in":["***","***"]},"deleted_at":{"_is_null":true}},"limit":10}`,
+		},
+		{
+			name:   "selected fields",
+			filter: `{"where":{"_or":[{"user":{"email":{"_eq":"a@b.c"}}},{"email":{"_eq":"x"}},{"age":{"_gt":18}}]}}`,
+			fields: []string{"user.email"},
+			want:   `{"where":{"_or":[{"user":{"email":{"_eq":"***"}}},{"email":{"_eq":"x"}},{"age":{"_gt":18}}]}}`,
+		},
+		{
+			name:   "object operands",
+			filter: `{"where":{"data":{"_contains":{"ssn":"123"}}}}`,
+			want:   `{"where":{"data":{"_contains":"***"}}}`,
+		},
+		{
+			name:   "annotations are always redacted",
+			filter: `{"where":{"_meta":{"owner":"jane@x.y","tags":["a"]},"age":{"_gt":18,"_comment":"for jane"}}}`,
+			fields: []string{"email"},
+			want:   `{"where":{"age":{"_gt":18,"_comment":"***"},"_meta":{"owner":"***","tags":["***"]}}}`,
+		},
+		{
+			name:   "other sections and aggregate options without fields",
+			filter: `{"search":{"q":"jane","exact":true},"_comment":"jane's view","aggregate":{"percentile_cont":{"field":"salary","percentile":0.5},"count":"*"},"order_by":{"age":"asc"}}`,
+			want:   `{"order_by":[{"age":"asc"}],"aggregate":{"percentile_cont":{"field":"salary","percentile":"***"},"count":"*"},"_comment":"***","search":{"exact":"***","q":"***"}}`,
+		},
+		{
+			name:   "other sections are kept with fields except annotations",
+			filter: `{"search":{"q":"jane"},"_meta":{"owner":"x"},"aggregate":{"percentile_cont":{"field":"salary","percentile":0.5}}}`,
+			fields: []string{"email"},
+			want:   `{"aggregate":{"percentile_cont":{"field":"salary","percentile":0.5}},"_meta":{"owner":"***"},"search":{"q":"jane"}}`,
+		},
+		{
+			name:   "invalid json",
+			filter: `{"where":{"email":{"_eq":"a@b.c"},"age":{"_gt":18,"_lt":1e3}},"limit":`,
+			want:   `{"where":{"email":{"_eq":"***"},"age":{"_gt":"***","_lt":"***"}},"limit":`,
+		},
+		{
+			name:   "invalid json with escapes and bare words",
+			filter: `{"where":{"name":{"_eq":"a \"quoted\" b"}, email: jane@x.y, "unterminated`,
+			want:   `{"where":{"name":{"_eq":"***"}, "***": "***", "***"`,
+		},
+		{
+			name:   "invalid filter is redacted as text",
+			filter: `{"where":{"_eq":"jane"}}`,
+			fields: []string{"email"},
+			want:   `{"where":{"_eq":"***"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Redact(tt.filter, tt.fields...); got != tt.want {
+				t.Errorf("Redact() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedacting(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	logger.Info("query", "filter", Redacting(`{"where":{"email":{"_eq":"jane@x.y"}}}`))
+
+	if strings.Contains(buf.String(), "jane") {
+		t.Errorf("log output %q contains the redacted value", buf.String())
+	}
+	if !strings.Contains(buf.String(), `\"_eq\":\"***\"`) {
+		t.Errorf("log output %q does not contain the redacted filter", buf.String())
+	}
+}
@@ -0,0 +1,140 @@
+package rewrite
+
+import (
+	"encoding/json"
+
+	"github.com/jmag-ic/gosura/pkg/ast"
+)
+
+// Optimize is a Pass that simplifies the where section without changing its
+// meaning:
+//
+//   - nested _and/_or groups of the same operator are flattened and groups
+//     with a single child are replaced by the child;
+//   - duplicate conditions within a group are removed;
+//   - double _not is eliminated;
+//   - constant conditions are folded, using Hasura's semantics where
+//     {} and _and: [] are true, _or: [] is false, _in: [] is false and
+//     _nin: [] is true.
+//
+// Groups carrying _meta/_comment annotations are not flattened away. A where
+// section that folds to true is removed; one that folds to false becomes
+// {"_or": []}.
+func Optimize(f *ast.Filter) (*ast.Filter, error) {
+	out, err := Where(optimizeNode)(f)
+	if err != nil {
+		return nil, err
+	}
+	if isTrue(out.Where) {
+		out.Where = nil
+	}
+	return out, nil
+}
+
+func optimizeNode(node ast.WhereNode, _ []string) (ast.WhereNode, error) {
+	switch n := node.(type) {
+	case *ast.Comparison:
+		if list, ok := n.Value.([]any); ok && len(list) == 0 {
+			switch n.Operator {
+			case "_in":
+				return constant(false), nil
+			case "_nin":
+				return constant(true), nil
+			}
+		}
+		return n, nil
+	case *ast.Relation:
+		switch {
+		case isFalse(n.Where):
+			return constant(false), nil
+		case isTrue(n.Where):
+			n.Where = nil
+		}
+		return n, nil
+	case *ast.LogicalGroup:
+		if n.Op == ast.Not {
+			return optimizeNot(n), nil
+		}
+		return optimizeGroup(n)
+	default:
+		return node, nil
+	}
+}
+
+func optimizeNot(g *ast.LogicalGroup) ast.WhereNode {
+	child := g.Children[0]
+	switch {
+	case isTrue(child):
+		return constant(false)
+	case isFalse(child):
+		return constant(true)
+	}
+	if inner, ok := child.(*ast.LogicalGroup); ok && inner.Op == ast.Not && g.Meta == nil && inner.Meta == nil {
+		return inner.Children[0]
+	}
+	return g
+}
+
+func optimizeGroup(g *ast.LogicalGroup) (ast.WhereNode, error) {
+	// identity is the constant that has no effect in g, absorbing the one
+	// that decides it.
+	identity, absorbing := isTrue, isFalse
+	if g.Op == ast.Or {
+		identity, absorbing = isFalse, isTrue
+	}
+
+	var (
+		children []ast.WhereNode
+		seen     = make(map[string]bool)
+		pending  = append([]ast.WhereNode(nil), g.Children...)
+	)
+	for len(pending) > 0 {
+		child := pending[0]
+		pending = pending[1:]
+
+		if inner, ok := child.(*ast.LogicalGroup); ok && inner.Op == g.Op && inner.Meta == nil && len(inner.Children) > 0 {
+			pending = append(append([]ast.WhereNode(nil), inner.Children...), pending...)
+			continue
+		}
+		if identity(child) {
+			continue
+		}
+		if absorbing(child) {
+			return constant(g.Op == ast.Or), nil
+		}
+
+		b, err := json.Marshal(child)
+		if err != nil {
+			return nil, err
+		}
+		if seen[string(b)] {
+			continue
+		}
+		seen[string(b)] = true
+		children = append(children, child)
+	}
+
+	if len(children) == 1 && g.Meta == nil {
+		return children[0], nil
+	}
+	return &ast.LogicalGroup{Op: g.Op, Children: children, Implicit: g.Implicit, Meta: g.Meta}, nil
+}
+
+// constant returns the node for a constant condition: {} for true and
+// {"_or": []} for false.
+func constant(value bool) ast.WhereNode {
+	if value {
+		return &ast.LogicalGroup{Op: ast.And, Implicit: true}
+	}
+	return &ast.LogicalGroup{Op: ast.Or}
+}
+
+func isTrue(node ast.WhereNode) bool {
+	g, ok := node.(*ast.LogicalGroup)
+	return ok && g.Op == ast.And && len(g.Children) == 0 && g.Meta == nil
+}
+
+func isFalse(node ast.WhereNode) bool {
+	g, ok := node.(*ast.LogicalGroup)
+	return ok && g.Op == ast.Or && len(g.Children) == 0 && g.Meta == nil
+}
@@ -0,0 +1,114 @@
+package rewrite
+
+import "testing"
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		want   string
+	}{
+		{
+			name:   "no where",
+			filter: `{"limit":1}`,
+			want:   `{"limit":1}`,
+		},
+		{
+			name:   "nested groups of the same operator are flattened",
+			filter: `{"where":{"_and":[{"a":{"_eq":1}},{"_and":[{"b":{"_eq":2}},{"_and":[{"c":{"_eq":3}}]}]}]}}`,
+			want:   `{"where":{"_and":[{"a":{"_eq":1}},{"b":{"_eq":2}},{"c":{"_eq":3}}]}}`,
+		},
+		{
+			name:   "single child groups are unwrapped",
+			filter: `{"where":{"_or":[{"_and":[{"a":{"_eq":1}}]}]}}`,
+			want:   `{"where":{"a":{"_eq":1}}}`,
+		},
+		{
+			name:   "duplicates are removed",
+			filter: `{"where":{"_or":[{"a":{"_eq":1}},{"b":{"_eq":2}},{"a":{"_eq":1}}]}}`,
+			want:   `{"where":{"_or":[{"a":{"_eq":1}},{"b":{"_eq":2}}]}}`,
+		},
+		{
+			name:   "double not",
+			filter: `{"where":{"_not":{"_not":{"a":{"_eq":1}}}}}`,
+			want:   `{"where":{"a":{"_eq":1}}}`,
+		},
+		{
+			name:   "_in empty is false",
+			filter: `{"where":{"a":{"_in":[]}}}`,
+			want:   `{"where":{"_or":[]}}`,
+		},
+		{
+			name:   "_nin empty is true",
+			filter: `{"where":{"a":{"_nin":[]}}}`,
+			want:   `{}`,
+		},
+		{
+			name:   "true is the identity of _and",
+			filter: `{"where":{"_and":[{},{"a":{"_eq":1}},{"_and":[]},{"b":{"_nin":[]}}]}}`,
+			want:   `{"where":{"a":{"_eq":1}}}`,
+		},
+		{
+			name:   "false absorbs _and",
+			filter: `{"where":{"a":{"_eq":1},"b":{"_in":[]}}}`,
+			want:   `{"where":{"_or":[]}}`,
+		},
+		{
+			name:   "false is the identity of _or",
+			filter: `{"where":{"_or":[{"a":{"_eq":1}},{"_or":[]},{"b":{"_in":[]}}]}}`,
+			want:   `{"where":{"a":{"_eq":1}}}`,
+		},
+		{
+			name:   "true absorbs _or",
+			filter: `{"where":{"_or":[{"a":{"_eq":1}},{}]}}`,
+			want:   `{}`,
+		},
+		{
+			name:   "empty _or stays false",
+			filter: `{"where":{"_or":[]}}`,
+			want:   `{"where":{"_or":[]}}`,
+		},
+		{
+			name:   "not of constants",
+			filter: `{"where":{"_or":[{"_not":{"a":{"_in":[]}}},{"b":{"_eq":1}}],"c":{"_not":{}}}}`,
+			want:   `{"where":{"_or":[]}}`,
+		},
+		{
+			name:   "relation to false is false",
+			filter: `{"where":{"_or":[{"user":{"a":{"_in":[]}}},{"b":{"_eq":1}}]}}`,
+			want:   `{"where":{"b":{"_eq":1}}}`,
+		},
+		{
+			name:   "relation to true keeps the relation",
+			filter: `{"where":{"user":{"_and":[]}}}`,
+			want:   `{"where":{"user":{}}}`,
+		},
+		{
+			name:   "annotated groups are kept",
+			filter: `{"where":{"_and":[{"_comment":"c","a":{"_eq":1}},{"b":{"_eq":2}}]}}`,
+			want:   `{"where":{"_and":[{"a":{"_eq":1},"_comment":"c"},{"b":{"_eq":2}}]}}`,
+		},
+		{
+			name:   "annotated double not is kept",
+			filter: `{"where":{"_not":{"_comment":"c","_not":{"a":{"_eq":1}}}}}`,
+			want:   `{"where":{"_not":{"_not":{"a":{"_eq":1}},"_comment":"c"}}}`,
+		},
+		{
+			name:   "other sections are untouched",
+			filter: `{"where":{"_and":[{"a":{"_eq":1}}]},"order_by":{"a":"asc"},"limit":5}`,
+			want:   `{"where":{"a":{"_eq":1}},"order_by":[{"a":"asc"}],"limit":5}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Rewrite(tt.filter, Optimize)
+			if err != nil {
+				t.Fatalf("Rewrite() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Optimize() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+// Package rewrite transforms filter documents through composable passes over
+// their ast form, e.g. to migrate clients off renamed fields or deprecated
+// operators.
+//
+//	out, err := rewrite.Rewrite(filter,
+//		rewrite.RenameField("user.mail", "email"),
+//		rewrite.RenameOperator("_similar", "_like"),
+//		rewrite.DropFields("internal_score"),
+//		rewrite.DefaultLimit(50),
+//	)
+//
+// Field paths use dots to address relations.
+package rewrite
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jmag-ic/gosura/pkg/ast"
+)
+
+// Pass transforms a filter. Passes must not modify their input.
+type Pass func(f *ast.Filter) (*ast.Filter, error)
+
+// Rewrite parses filter, applies passes in order and returns the resulting
+// filter document.
+func Rewrite(filter string, passes ...Pass) (string, error) {
+	f, err := ast.Parse(filter)
+	if err != nil {
+		return "", err
+	}
+	f, err = Apply(f, passes...)
+	if err != nil {
+		return "", err
+	}
+	return f.JSON()
+}
+
+// Apply applies passes to f in order.
+func Apply(f *ast.Filter, passes ...Pass) (*ast.Filter, error) {
+	for _, pass := range passes {
+		var err error
+		if f, err = pass(f); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Where returns a Pass that applies fn to every where node, as ast.Transform
+// does.
+func Where(fn ast.TransformFunc) Pass {
+	return func(f *ast.Filter) (*ast.Filter, error) {
+		out := *f
+		where, err := ast.Transform(f.Where, fn)
+		if err != nil {
+			return nil, err
+		}
+		out.Where = where
+		return &out, nil
+	}
+}
+
+// RenameField renames the field at path to name wherever it appears in the
+// where, order_by and aggregate sections. Only the last segment of path is
+// renamed, so RenameField("user.mail", "email") turns user.mail into
+// user.email, including under it, e.g. user.mail.domain.
+func RenameField(path, name string) Pass {
+	segments := strings.Split(path, ".")
+	prefix, last := segments[:len(segments)-1], segments[len(segments)-1]
+	matches := func(p []string) bool {
+		return len(p) > len(prefix) && slices.Equal(p[:len(prefix)], prefix) && p[len(prefix)] == last
+	}
+	rename := func(p []string) []string {
+		p = append([]string(nil), p...)
+		if matches(p) {
+			p[len(prefix)] = name
+		}
+		return p
+	}
+
+	return func(f *ast.Filter) (*ast.Filter, error) {
+		out, err := Where(func(node ast.WhereNode, p []string) (ast.WhereNode, error) {
+			if !slices.Equal(p, prefix) {
+				return node, nil
+			}
+			switch n := node.(type) {
+			case *ast.Comparison:
+				if n.Field == last {
+					n.Field = name
+				}
+			case *ast.Relation:
+				if n.Field == last {
+					n.Field = name
+				}
+			}
+			return node, nil
+		})(f)
+		if err != nil {
+			return nil, err
+		}
+
+		out.OrderBy = make([]ast.OrderBy, len(f.OrderBy))
+		for i, entry := range f.OrderBy {
+			entry.Path = rename(entry.Path)
+			out.OrderBy[i] = entry
+		}
+
+		out.Aggregates = make([]ast.Aggregate, len(f.Aggregates))
+		for i, agg := range f.Aggregates {
+			agg.Fields = append([]string(nil), agg.Fields...)
+			for j, field := range agg.Fields {
+				agg.Fields[j] = strings.Join(rename(strings.Split(field, ".")), ".")
+			}
+			out.Aggregates[i] = agg
+		}
+		return out, nil
+	}
+}
+
+// RenameOperator replaces the comparison operator from with to, for
+// migrating clients off deprecated operators.
+func RenameOperator(from, to string) Pass {
+	return Where(func(node ast.WhereNode, _ []string) (ast.WhereNode, error) {
+		if c, ok := node.(*ast.Comparison); ok && c.Operator == from {
+			c.Operator = to
+		}
+		return node, nil
+	})
+}
+
+// DropFields removes every condition, order_by entry and aggregate on the
+// given field paths. Dropping a relation path drops everything under it,
+// e.g. DropFields("user") also drops {"sum": "user.salary"}.
+// Groups left without conditions are removed, see ast.Transform.
+func DropFields(paths ...string) Pass {
+	dropped := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		dropped[path] = true
+	}
+	isDropped := func(segments []string) bool {
+		for i := range segments {
+			if dropped[strings.Join(segments[:i+1], ".")] {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(f *ast.Filter) (*ast.Filter, error) {
+		out, err := Where(func(node ast.WhereNode, p []string) (ast.WhereNode, error) {
+			var field string
+			switch n := node.(type) {
+			case *ast.Comparison:
+				field = n.Field
+			case *ast.Relation:
+				field = n.Field
+			default:
+				return node, nil
+			}
+			if isDropped(append(p[:len(p):len(p)], field)) {
+				return nil, nil
+			}
+			return node, nil
+		})(f)
+		if err != nil {
+			return nil, err
+		}
+
+		out.OrderBy = nil
+		for _, entry := range f.OrderBy {
+			if !isDropped(entry.Path) {
+				out.OrderBy = append(out.OrderBy, entry)
+			}
+		}
+
+		out.Aggregates = nil
+	aggregates:
+		for _, agg := range f.Aggregates {
+			for _, field := range agg.Fields {
+				if isDropped(strings.Split(field, ".")) {
+					continue aggregates
+				}
+			}
+			out.Aggregates = append(out.Aggregates, agg)
+		}
+		return out, nil
+	}
+}
+
+// DropIf removes every comparison for which drop returns true. path is the
+// relation path leading to the comparison.
+func DropIf(drop func(c *ast.Comparison, path []string) bool) Pass {
+	return Where(func(node ast.WhereNode, p []string) (ast.WhereNode, error) {
+		if c, ok := node.(*ast.Comparison); ok && drop(c, p) {
+			return nil, nil
+		}
+		return node, nil
+	})
+}
+
+// DefaultWhere sets the where section to where, a where object in JSON, when
+// the filter has none.
+func DefaultWhere(where string) Pass {
+	parsed, parseErr := ast.ParseWhere(where)
+	return func(f *ast.Filter) (*ast.Filter, error) {
+		if parseErr != nil {
+			return nil, fmt.Errorf("rewrite: default where: %w", parseErr)
+		}
+		if f.Where != nil {
+			return f, nil
+		}
+		out := *f
+		out.Where = parsed
+		return &out, nil
+	}
+}
+
+// DefaultOrderBy sets the order_by section when the filter has none.
+func DefaultOrderBy(entries ...ast.OrderBy) Pass {
+	return func(f *ast.Filter) (*ast.Filter, error) {
+		if len(f.OrderBy) > 0 {
+			return f, nil
+		}
+		out := *f
+		out.OrderBy = append([]ast.OrderBy(nil), entries...)
+		return &out, nil
+	}
+}
+
+// DefaultLimit sets the limit section when the filter has none.
+func DefaultLimit(n int64) Pass {
+	return func(f *ast.Filter) (*ast.Filter, error) {
+		if f.Limit != nil {
+			return f, nil
+		}
+		out := *f
+		limit := n
+		out.Limit = &limit
+		return &out, nil
+	}
+}
@@ -0,0 +1,146 @@
+package rewrite
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jmag-ic/gosura/pkg/ast"
+)
+
+func TestRewrite(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		passes []Pass
+		want   string
+	}{
+		{
+			name:   "no passes",
+			filter: `{"where":{"b":{"_eq":2},"a":{"_eq":1}}}`,
+			want:   `{"where":{"b":{"_eq":2},"a":{"_eq":1}}}`,
+		},
+		{
+			name:   "rename top-level field",
+			filter: `{"where":{"mail":{"_eq":"x"},"user":{"mail":{"_eq":"y"}}},"order_by":{"mail":"asc"},"aggregate":{"count":"mail","max":"user.mail"}}`,
+			passes: []Pass{RenameField("mail", "email")},
+			want:   `{"where":{"email":{"_eq":"x"},"user":{"mail":{"_eq":"y"}}},"order_by":[{"email":"asc"}],"aggregate":{"count":"email","max":"user.mail"}}`,
+		},
+		{
+			name:   "rename relation field",
+			filter: `{"where":{"mail":{"_eq":"x"},"user":{"mail":{"_eq":"y"}}},"order_by":{"user":{"mail":"asc"}},"aggregate":{"count":"user.mail","max":"mail"}}`,
+			passes: []Pass{RenameField("user.mail", "email")},
+			want:   `{"where":{"mail":{"_eq":"x"},"user":{"email":{"_eq":"y"}}},"order_by":[{"user":{"email":"asc"}}],"aggregate":{"count":"user.email","max":"mail"}}`,
+		},
+		{
+			name:   "rename relation renames paths under it",
+			filter: `{"where":{"author":{"name":{"_eq":"x"}}},"order_by":{"author":{"name":"asc"}},"aggregate":{"max":"author.name"}}`,
+			passes: []Pass{RenameField("author", "user")},
+			want:   `{"where":{"user":{"name":{"_eq":"x"}}},"order_by":[{"user":{"name":"asc"}}],"aggregate":{"max":"user.name"}}`,
+		},
+		{
+			name:   "rename does not touch deeper fields of the same name",
+			filter: `{"where":{"user":{"mail":{"mail":{"_eq":"x"}}}}}`,
+			passes: []Pass{RenameField("user.mail", "email")},
+			want:   `{"where":{"user":{"email":{"mail":{"_eq":"x"}}}}}`,
+		},
+		{
+			name:   "rename operator",
+			filter: `{"where":{"_or":[{"a":{"_similar":"x%"}},{"b":{"_eq":1}}]}}`,
+			passes: []Pass{RenameOperator("_similar", "_like")},
+			want:   `{"where":{"_or":[{"a":{"_like":"x%"}},{"b":{"_eq":1}}]}}`,
+		},
+		{
+			name:   "drop field",
+			filter: `{"where":{"a":{"_eq":1},"secret":{"_eq":2}},"order_by":[{"secret":"asc"},{"a":"desc"}],"aggregate":{"sum":"secret","count":"*"}}`,
+			passes: []Pass{DropFields("secret")},
+			want:   `{"where":{"a":{"_eq":1}},"order_by":[{"a":"desc"}],"aggregate":{"count":"*"}}`,
+		},
+		{
+			name:   "drop relation drops everything under it",
+			filter: `{"where":{"a":{"_eq":1},"user":{"secret":{"_eq":2}}},"order_by":{"user":{"name":"asc"}},"aggregate":{"sum":"user.secret","max":"a"}}`,
+			passes: []Pass{DropFields("user")},
+			want:   `{"where":{"a":{"_eq":1}},"aggregate":{"max":"a"}}`,
+		},
+		{
+			name:   "drop removes groups left empty",
+			filter: `{"where":{"_not":{"secret":{"_eq":1}},"_or":[{"secret":{"_eq":2}}],"a":{"_eq":1}}}`,
+			passes: []Pass{DropFields("secret")},
+			want:   `{"where":{"a":{"_eq":1}}}`,
+		},
+		{
+			name:   "drop if",
+			filter: `{"where":{"a":{"_in":[1,2]},"b":{"_eq":1}}}`,
+			passes: []Pass{DropIf(func(c *ast.Comparison, _ []string) bool { return c.Operator == "_in" })},
+			want:   `{"where":{"b":{"_eq":1}}}`,
+		},
+		{
+			name:   "defaults fill missing sections",
+			filter: `{}`,
+			passes: []Pass{
+				DefaultWhere(`{"deleted_at":{"_is_null":true}}`),
+				DefaultOrderBy(ast.OrderBy{Path: []string{"id"}, Direction: "asc"}),
+				DefaultLimit(50),
+			},
+			want: `{"where":{"deleted_at":{"_is_null":true}},"order_by":[{"id":"asc"}],"limit":50}`,
+		},
+		{
+			name:   "defaults keep existing sections",
+			filter: `{"where":{"a":{"_eq":1}},"order_by":{"b":"desc"},"limit":10}`,
+			passes: []Pass{
+				DefaultWhere(`{"deleted_at":{"_is_null":true}}`),
+				DefaultOrderBy(ast.OrderBy{Path: []string{"id"}, Direction: "asc"}),
+				DefaultLimit(50),
+			},
+			want: `{"where":{"a":{"_eq":1}},"order_by":[{"b":"desc"}],"limit":10}`,
+		},
+		{
+			name:   "empty default where",
+			filter: `{}`,
+			passes: []Pass{DefaultWhere(`{}`)},
+			want:   `{}`,
+		},
+		{
+			name:   "passes run in order",
+			filter: `{"where":{"old":{"_eq":1}}}`,
+			passes: []Pass{RenameField("old", "new"), DropFields("old")},
+			want:   `{"where":{"new":{"_eq":1}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Rewrite(tt.filter, tt.passes...)
+			if err != nil {
+				t.Fatalf("Rewrite() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Rewrite() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewriteErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		passes []Pass
+		want   string
+	}{
+		{name: "invalid filter", filter: `{"where":`, want: "unexpected EOF"},
+		{name: "invalid default where", filter: `{}`, passes: []Pass{DefaultWhere(`{"a":1}`)}, want: "rewrite: default where: ast: where.a: invalid node: expected object"},
+		{name: "default where with injected sections", filter: `{}`, passes: []Pass{DefaultWhere(`{}, "limit": 1`)}, want: "unexpected data"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Rewrite(tt.filter, tt.passes...)
+			if err == nil {
+				t.Fatal("Rewrite() error = nil, want error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Rewrite() error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}